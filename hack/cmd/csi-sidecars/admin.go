@@ -0,0 +1,81 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/kubernetes-csi/csi-lib-utils/standardflags"
+	"github.com/kubernetes-csi/csi-sidecars/cmd/csi-sidecars/config"
+	"github.com/kubernetes-csi/csi-sidecars/cmd/csi-sidecars/diagnostics"
+	"github.com/kubernetes-csi/csi-sidecars/pkg/admin"
+)
+
+// selectedNodeAnnotation is set on a PVC by the scheduler when its
+// StorageClass uses WaitForFirstConsumer.
+const selectedNodeAnnotation = "volume.kubernetes.io/selected-node"
+
+// setupAdminAPI mounts the admin API on the diagnostics server, on
+// --http-endpoint with --enable-admin-api and on --admin-socket. The
+// VolumeAttachments, PVs and PVCs are read from the informers of the sidecars
+// so that their processing can be paused per node and the queues holding them
+// can be resynced, see useSidecarInformers.
+func setupAdminAPI(server *diagnostics.Server) error {
+	if config.Configuration.EnableAdminAPI && !config.Configuration.HTTPEndpointDelegatedAuth {
+		return fmt.Errorf("--enable-admin-api requires --http-endpoint-delegated-auth")
+	}
+	admin.RegisterNodeResolver("VolumeAttachment", volumeAttachmentNode)
+	admin.RegisterNodeResolver("PersistentVolumeClaim", claimSelectedNode)
+	for _, kind := range []string{"VolumeAttachment", "PersistentVolume", "PersistentVolumeClaim"} {
+		admin.RegisterLister(kind, func() ([]string, error) {
+			return listSharedKeys(kind)
+		})
+	}
+
+	if config.Configuration.EnableAdminAPI {
+		server.Handle(admin.PathPrefix, admin.Handler())
+	} else {
+		server.HandleLocal(admin.PathPrefix, admin.Handler())
+	}
+	server.AddHealthDetail("paused", admin.PausedSummary)
+	return nil
+}
+
+// newClientset returns a Clientset for the AIO binary itself, built from
+// --master and --kubeconfig like the sidecars build theirs.
+func newClientset() (kubernetes.Interface, error) {
+	restConfig, err := clientcmd.BuildConfigFromFlags(config.Configuration.Master, standardflags.Configuration.KubeConfig)
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(restConfig)
+}
+
+// newControllerClientset returns a Clientset with the identity of a
+// controller, see useControllerIdentity.
+func newControllerClientset(controller string) (kubernetes.Interface, error) {
+	restConfig, err := clientcmd.BuildConfigFromFlags(config.Configuration.Master, standardflags.Configuration.KubeConfig)
+	if err != nil {
+		return nil, err
+	}
+	restConfig.UserAgent = controllerUserAgent(controller)
+	useControllerIdentity(controller, restConfig)
+	return kubernetes.NewForConfig(restConfig)
+}
@@ -0,0 +1,203 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// Controllers are the sidecars the AIO binary can run, see --controllers.
+var Controllers = []string{"attacher", "provisioner", "resizer"}
+
+// StandaloneAliases are the flags of the standalone sidecars that the AIO
+// binary has under another name, by sidecar. They're registered as aliases
+// when the binary runs as a standalone sidecar, e.g. csi-resizer.
+var StandaloneAliases = map[string]map[string]string{
+	"provisioner": {
+		"worker-threads": "attacher-worker-threads",
+		"timeout":        "attacher-timeout",
+		"default-fstype": "attacher-default-fstype",
+	},
+	"resizer": {
+		"workers":       "attacher-worker-threads",
+		"timeout":       "attacher-timeout",
+		"resync-period": "resync",
+	},
+}
+
+// NormalizeArgs rewrites the long flags with a single dash in args, e.g.
+// -csi-address, which the standalone sidecars accept, to two dashes. Single
+// letter flags like -v are shorthands already.
+func NormalizeArgs(args []string) []string {
+	normalized := make([]string, 0, len(args))
+	for i, arg := range args {
+		if arg == "--" {
+			normalized = append(normalized, args[i:]...)
+			break
+		}
+		name, _, _ := strings.Cut(arg, "=")
+		if len(name) > 2 && name[0] == '-' && isLetter(name[1]) {
+			arg = "-" + arg
+		}
+		normalized = append(normalized, arg)
+	}
+	return normalized
+}
+
+func isLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// renamedFlags are the flags of the standalone sidecars whose AIO flag has
+// another name besides the controller prefix, e.g. --workers of the resizer
+// is --worker-threads like in the other sidecars.
+var renamedFlags = map[string]string{
+	"workers":       "worker-threads",
+	"resync-period": "resync",
+}
+
+// LegacyFlag is an unprefixed flag of the standalone sidecars found on the
+// command line, see ExtractLegacyFlags.
+type LegacyFlag struct {
+	Name  string
+	Value string
+}
+
+// ExtractLegacyFlags removes the flags of the standalone sidecars from args,
+// e.g. --worker-threads for --attacher-worker-threads, so that the remaining
+// args can be parsed. They're resolved with ResolveLegacyFlags once every
+// source of --controllers is applied.
+func ExtractLegacyFlags(flags *pflag.FlagSet, args []string) ([]string, []LegacyFlag, error) {
+	rest := make([]string, 0, len(args))
+	var legacy []LegacyFlag
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		if !strings.HasPrefix(arg, "--") {
+			rest = append(rest, arg)
+			continue
+		}
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		if name == "" || flags.Lookup(name) != nil {
+			rest = append(rest, arg)
+			continue
+		}
+		target := legacyFlagTarget(flags, name)
+		if target == nil {
+			// Unknown, let the parser report it.
+			rest = append(rest, arg)
+			continue
+		}
+		switch {
+		case hasValue:
+		case target.NoOptDefVal != "":
+			value = target.NoOptDefVal
+		case i+1 < len(args):
+			i++
+			value = args[i]
+		default:
+			return nil, nil, fmt.Errorf("flag needs an argument: --%s", name)
+		}
+		legacy = append(legacy, LegacyFlag{Name: name, Value: value})
+	}
+	return rest, legacy, nil
+}
+
+// legacyFlagTarget returns one of the AIO flags an unprefixed flag may stand
+// for, or nil.
+func legacyFlagTarget(flags *pflag.FlagSet, name string) *pflag.Flag {
+	if renamed, ok := renamedFlags[name]; ok {
+		name = renamed
+	}
+	if f := flags.Lookup(name); f != nil {
+		return f
+	}
+	for _, controller := range Controllers {
+		if f := flags.Lookup(controller + "-" + name); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// ResolveLegacyFlags sets the AIO flags the legacy flags stand for. An
+// unprefixed flag is only resolved when a single controller has it,
+// preferring the enabled controllers, and it's an error when several
+// enabled controllers have it. The legacy flags override the environment
+// variables and the --config file like the other flags, but not the AIO
+// flag when it's set on the command line too. It returns the names of the
+// flags it set, and warnings about the deprecated names.
+func ResolveLegacyFlags(flags *pflag.FlagSet, legacy []LegacyFlag, controllers string, commandLine map[string]bool) (map[string]bool, []string, error) {
+	enabled := map[string]bool{}
+	for _, controller := range strings.Split(controllers, ",") {
+		if controller = strings.TrimSpace(controller); controller != "" {
+			enabled[controller] = true
+		}
+	}
+	set := map[string]bool{}
+	var warnings []string
+	for _, f := range legacy {
+		name := f.Name
+		if renamed, ok := renamedFlags[name]; ok {
+			name = renamed
+		}
+		resolved := name
+		if flags.Lookup(name) == nil {
+			var err error
+			if resolved, err = resolveLegacyFlag(flags, name, enabled); err != nil {
+				return nil, warnings, err
+			}
+		}
+		if commandLine[resolved] {
+			warnings = append(warnings, fmt.Sprintf("Ignoring --%s, --%s is set", f.Name, resolved))
+			continue
+		}
+		if err := flags.Set(resolved, f.Value); err != nil {
+			return nil, warnings, fmt.Errorf("invalid argument %q for --%s: %w", f.Value, f.Name, err)
+		}
+		SetSource(resolved, SourceFlag)
+		set[resolved] = true
+		warnings = append(warnings, fmt.Sprintf("--%s is deprecated in the AIO binary, use --%s", f.Name, resolved))
+	}
+	return set, warnings, nil
+}
+
+// resolveLegacyFlag returns the prefixed flag an unprefixed flag stands for,
+// or an empty string when no controller has it.
+func resolveLegacyFlag(flags *pflag.FlagSet, name string, enabled map[string]bool) (string, error) {
+	var candidates, enabledCandidates []string
+	for _, controller := range Controllers {
+		if flags.Lookup(controller+"-"+name) == nil {
+			continue
+		}
+		candidates = append(candidates, controller)
+		if enabled[controller] {
+			enabledCandidates = append(enabledCandidates, controller)
+		}
+	}
+	switch {
+	case len(enabledCandidates) == 1:
+		return enabledCandidates[0] + "-" + name, nil
+	case len(enabledCandidates) > 1:
+		return "", ambiguousFlagError(name, enabledCandidates)
+	case len(candidates) == 1:
+		return candidates[0] + "-" + name, nil
+	case len(candidates) > 1:
+		return "", ambiguousFlagError(name, candidates)
+	}
+	return "", nil
+}
+
+func ambiguousFlagError(name string, controllers []string) error {
+	prefixed := make([]string, 0, len(controllers))
+	for _, controller := range controllers {
+		prefixed = append(prefixed, "--"+controller+"-"+name)
+	}
+	sort.Strings(prefixed)
+	return fmt.Errorf("--%s is ambiguous, it's used by the %s controllers: set %s instead", name, strings.Join(controllers, ", "), strings.Join(prefixed, " and "))
+}
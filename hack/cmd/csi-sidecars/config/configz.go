@@ -0,0 +1,135 @@
+package config
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/spf13/pflag"
+)
+
+// ConfigzPath serves the effective configuration, see Resolve.
+const ConfigzPath = "/configz"
+
+// Source tells where the value of a flag comes from.
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceFlag    Source = "flag"
+	SourceEnv     Source = "env"
+	SourceFile    Source = "file"
+)
+
+var (
+	sourcesMu sync.RWMutex
+	sources   = map[string]Source{}
+)
+
+// SetSource records where the value of a flag comes from, the flags without
+// a source have their default value.
+func SetSource(name string, source Source) {
+	sourcesMu.Lock()
+	defer sourcesMu.Unlock()
+	if source == SourceDefault {
+		delete(sources, name)
+		return
+	}
+	sources[name] = source
+}
+
+func sourceOf(name string) Source {
+	sourcesMu.RLock()
+	defer sourcesMu.RUnlock()
+	if source, ok := sources[name]; ok {
+		return source
+	}
+	return SourceDefault
+}
+
+// Value is the effective value of a setting.
+type Value struct {
+	Value  string `json:"value"`
+	Source Source `json:"source"`
+	// Flag is the flag the value comes from, for the settings of a
+	// controller.
+	Flag string `json:"flag,omitempty"`
+}
+
+// Configz is the effective configuration of the process and of every
+// controller it runs.
+type Configz struct {
+	// Process has the flags that don't belong to a controller.
+	Process map[string]Value `json:"process"`
+	// Controllers has the settings of every controller by their standalone
+	// flag name, e.g. worker-threads for --attacher-worker-threads.
+	Controllers map[string]map[string]Value `json:"controllers"`
+}
+
+// Resolve returns the effective configuration. owner returns the controller
+// a flag belongs to and the name of the setting, or "" for the flags of the
+// process. shared are the settings every controller reads from one flag by
+// the name of the flag, e.g. the timeout of every sidecar is
+// --attacher-timeout. The values of secret-like flags are redacted.
+func Resolve(flags *pflag.FlagSet, controllers []string, owner func(name string) (controller, setting string), shared map[string]string) Configz {
+	c := Configz{
+		Process:     map[string]Value{},
+		Controllers: map[string]map[string]Value{},
+	}
+	for _, controller := range controllers {
+		c.Controllers[controller] = map[string]Value{}
+	}
+	flags.VisitAll(func(f *pflag.Flag) {
+		value := Value{Value: redact(f.Name, f.Value.String()), Source: sourceOf(f.Name)}
+		controller, setting := owner(f.Name)
+		if controller == "" {
+			c.Process[f.Name] = value
+			return
+		}
+		if settings, ok := c.Controllers[controller]; ok {
+			value.Flag = f.Name
+			settings[setting] = value
+		}
+	})
+
+	settings := make([]string, 0, len(shared))
+	for setting := range shared {
+		settings = append(settings, setting)
+	}
+	sort.Strings(settings)
+	for _, controller := range controllers {
+		for _, setting := range settings {
+			if _, ok := c.Controllers[controller][setting]; ok {
+				continue
+			}
+			name := shared[setting]
+			if f := flags.Lookup(name); f != nil {
+				c.Controllers[controller][setting] = Value{Value: redact(name, f.Value.String()), Source: sourceOf(name), Flag: name}
+			}
+		}
+	}
+	return c
+}
+
+// secretFlag matches the flags whose values are redacted.
+var secretFlag = regexp.MustCompile(`(?i)(token|password|secret|credential|header)`)
+
+const redacted = "<redacted>"
+
+// redact hides the value of secret-like flags, and the password of URLs.
+func redact(name, value string) string {
+	if value == "" {
+		return value
+	}
+	if secretFlag.MatchString(name) {
+		return redacted
+	}
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+			return u.String()
+		}
+	}
+	return value
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// EnvPrefix is the prefix of the environment variables that set the flags,
+// e.g. CSI_SIDECARS_ATTACHER_WORKER_THREADS sets --attacher-worker-threads.
+const EnvPrefix = "CSI_SIDECARS_"
+
+// EnvName returns the environment variable that sets a flag.
+func EnvName(flag string) string {
+	return EnvPrefix + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(flag))
+}
+
+// ApplyEnv sets the flags from their environment variables, except the flags
+// set on the command line. It returns the names of the flags it set, and
+// warnings about the environment variables that don't set any flag.
+func ApplyEnv(flags *pflag.FlagSet, commandLine map[string]bool) (map[string]bool, []string, error) {
+	names := map[string]string{}
+	flags.VisitAll(func(f *pflag.Flag) {
+		names[EnvName(f.Name)] = f.Name
+	})
+
+	set := map[string]bool{}
+	var warnings []string
+	env := os.Environ()
+	sort.Strings(env)
+	for _, kv := range env {
+		key, value, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(key, EnvPrefix) {
+			continue
+		}
+		name, ok := names[key]
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("Ignoring the environment variable %s, it doesn't set any flag", key))
+			continue
+		}
+		if commandLine[name] {
+			continue
+		}
+		if err := flags.Set(name, value); err != nil {
+			return nil, nil, fmt.Errorf("invalid value of %s for --%s: %w", key, name, err)
+		}
+		SetSource(name, SourceEnv)
+		set[name] = true
+	}
+	return set, warnings, nil
+}
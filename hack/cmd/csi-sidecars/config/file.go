@@ -0,0 +1,86 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+)
+
+// ReadFile reads the flag values of a --config file, a YAML map from flag
+// names to values, e.g.
+//
+//	attacher-worker-threads: 20
+//	retry-interval-max: 10m
+//	feature-gates: attacher:ReleaseLeaderElectionOnExit=true
+//
+// A list is joined with commas.
+func ReadFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	values := make(map[string]string, len(raw))
+	for name, value := range raw {
+		switch v := value.(type) {
+		case nil:
+			continue
+		case map[string]any:
+			return nil, fmt.Errorf("%s: the value of %s must be a scalar or a list", path, name)
+		case []any:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				items = append(items, fmt.Sprint(item))
+			}
+			values[name] = strings.Join(items, ",")
+		case float64:
+			// YAML numbers are decoded as float64, 20 must stay 20 rather
+			// than 2e+01.
+			values[name] = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			values[name] = fmt.Sprint(v)
+		}
+	}
+	return values, nil
+}
+
+// ApplyFile sets the flags to the values read from a --config file, except
+// the overridden flags, the ones set on the command line or by environment
+// variables.
+func ApplyFile(flags *pflag.FlagSet, values map[string]string, overridden map[string]bool) error {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if flags.Lookup(name) == nil {
+			return fmt.Errorf("unknown flag %q in --config", name)
+		}
+		if overridden[name] {
+			continue
+		}
+		if err := flags.Set(name, values[name]); err != nil {
+			return fmt.Errorf("invalid value of %q in --config: %w", name, err)
+		}
+		SetSource(name, SourceFile)
+	}
+	return nil
+}
+
+// ChangedFlags returns the names of the flags that were set.
+func ChangedFlags(flags *pflag.FlagSet) map[string]bool {
+	changed := map[string]bool{}
+	flags.Visit(func(f *pflag.Flag) {
+		changed[f.Name] = true
+	})
+	return changed
+}
@@ -2,9 +2,16 @@ package config
 
 import (
 	"flag"
+	"fmt"
 	"time"
 
+	"github.com/spf13/pflag"
+	basemetrics "k8s.io/component-base/metrics"
+
 	attacherconfiguration "github.com/kubernetes-csi/csi-sidecars/pkg/attacher/cmd/csi-attacher/config"
+	provisionerconfiguration "github.com/kubernetes-csi/csi-sidecars/pkg/provisioner/cmd/csi-provisioner/config"
+	resizerconfiguration "github.com/kubernetes-csi/csi-sidecars/pkg/resizer/cmd/csi-resizer/config"
+	"github.com/kubernetes-csi/csi-sidecars/pkg/tracing"
 )
 
 // AIOConfiguration holds AIO-specific flags that are not covered by
@@ -20,11 +27,57 @@ type AIOConfiguration struct {
 
 	Controllers string
 
-	AttacherConfiguration attacherconfiguration.AttacherConfiguration
+	// ConfigFile holds flag values, see ReadFile. It's reloaded when it
+	// changes or on SIGHUP.
+	ConfigFile string
+
+	// Verbosity is the log level of every controller, e.g. --attacher-v,
+	// a negative level uses -v. The global klog functions keep using -v.
+	Verbosity map[string]*int
+
+	// KubeAPIQPS and KubeAPIBurst are the Kubernetes API rate limit of every
+	// controller, e.g. --attacher-kube-api-qps, 0 uses --kube-api-qps and
+	// --kube-api-burst. KubeAPIMaxQPS and KubeAPIMaxBurst are the limit of
+	// all the controllers together, 0 doesn't limit them.
+	KubeAPIQPS      map[string]*float64
+	KubeAPIBurst    map[string]*int
+	KubeAPIMaxQPS   float64
+	KubeAPIMaxBurst int
+
+	// KubeConfig and ImpersonateServiceAccount are the identity of every
+	// controller, e.g. --attacher-kubeconfig, "" uses the identity of the
+	// process.
+	KubeConfig                map[string]*string
+	ImpersonateServiceAccount map[string]*string
+
+	// RBACCheck is what to do when a controller misses a permission at
+	// startup: none, warn or fail.
+	RBACCheck string
+
+	// Diagnostics server (--http-endpoint) security.
+	TLSCertFile               string
+	TLSPrivateKeyFile         string
+	ClientCAFile              string
+	HTTPEndpointDelegatedAuth bool
+	EnableAdminAPI            bool
+	AdminSocket               string
+
+	// Metrics holds the component-base metrics options (--disabled-metrics,
+	// --allow-metric-labels, etc.) applied to every registry the AIO binary exposes.
+	Metrics *basemetrics.Options
+
+	Tracing tracing.Options
+
+	AttacherConfiguration    attacherconfiguration.AttacherConfiguration
+	ProvisionerConfiguration provisionerconfiguration.ProvisionerConfiguration
+	ResizerConfiguration     resizerconfiguration.ResizerConfiguration
 }
 
 var Configuration = AIOConfiguration{
-	AttacherConfiguration: attacherconfiguration.AttacherConfiguration{},
+	Metrics:                  basemetrics.NewOptions(),
+	AttacherConfiguration:    attacherconfiguration.AttacherConfiguration{},
+	ProvisionerConfiguration: provisionerconfiguration.ProvisionerConfiguration{},
+	ResizerConfiguration:     resizerconfiguration.ResizerConfiguration{},
 }
 
 // RegisterAIOFlags registers AIO-specific flags that are not part of the
@@ -34,5 +87,39 @@ func RegisterAIOFlags(flags *flag.FlagSet) {
 	flags.DurationVar(&Configuration.Resync, "resync", 10*time.Minute, "Resync interval of the controller.")
 	flags.DurationVar(&Configuration.RetryIntervalStart, "retry-interval-start", time.Second, "Initial retry interval of failed create volume or deletion. It doubles with each failure, up to retry-interval-max.")
 	flags.DurationVar(&Configuration.RetryIntervalMax, "retry-interval-max", 5*time.Minute, "Maximum retry interval of failed create volume or deletion.")
+	flags.StringVar(&Configuration.ConfigFile, "config", "", "Path of a YAML file with flag values, e.g. `attacher-worker-threads: 20`. Flags set on the command line or by CSI_SIDECARS_<FLAG> environment variables take precedence, e.g. CSI_SIDECARS_ATTACHER_WORKER_THREADS for --attacher-worker-threads. "+
+		"The file is reloaded when it changes or on SIGHUP: the Kubernetes API QPS and burst, the retry intervals, the log levels, and lower worker counts and timeouts apply right away, other changes need a restart and are ignored.")
 	flags.StringVar(&Configuration.Controllers, "controllers", "", "A comma-separated list of controllers to enable. The possible values are: [resizer,attacher,provisioner]")
+	flags.StringVar(&Configuration.RBACCheck, "rbac-check", "none", "Check with SelfSubjectAccessReviews at startup that every enabled controller has the permissions it needs, like the RBAC rules deployed with the sidecars. The possible values are: none (don't check, the default), warn (log the missing permissions) and fail (exit when a permission is missing or couldn't be checked).")
+	flags.StringVar(&Configuration.TLSCertFile, "tls-cert-file", "", "File containing the x509 certificate used to serve HTTPS on --http-endpoint. The file is reloaded when it changes. Requires --tls-private-key-file.")
+	flags.StringVar(&Configuration.TLSPrivateKeyFile, "tls-private-key-file", "", "File containing the x509 private key matching --tls-cert-file.")
+	flags.StringVar(&Configuration.ClientCAFile, "client-ca-file", "", "If set, requests to --http-endpoint presenting a client certificate signed by one of the authorities in this file are authenticated with the certificate's CommonName. Requires --http-endpoint-delegated-auth.")
+	flags.BoolVar(&Configuration.HTTPEndpointDelegatedAuth, "http-endpoint-delegated-auth", false, "Authenticate requests to --http-endpoint with TokenReview and authorize them with SubjectAccessReview, e.g. `get` on the non-resource URL `/metrics`. /healthz is always served anonymously.")
+	Configuration.Verbosity = map[string]*int{}
+	for _, controller := range Controllers {
+		Configuration.Verbosity[controller] = flags.Int(controller+"-v", -1, fmt.Sprintf("Log level of the %s controller, it replaces -v for the logs the controller writes with its contextual logger. The logs written with the global klog functions, e.g. klog.V(4).InfoS, keep using -v. Negative values use -v.", controller))
+	}
+	Configuration.KubeAPIQPS = map[string]*float64{}
+	Configuration.KubeAPIBurst = map[string]*int{}
+	for _, controller := range Controllers {
+		Configuration.KubeAPIQPS[controller] = flags.Float64(controller+"-kube-api-qps", 0, fmt.Sprintf("QPS of all the clients of the %s controller to the Kubernetes API server, 0 uses --kube-api-qps.", controller))
+		Configuration.KubeAPIBurst[controller] = flags.Int(controller+"-kube-api-burst", 0, fmt.Sprintf("Burst of all the clients of the %s controller to the Kubernetes API server, 0 uses --kube-api-burst.", controller))
+	}
+	Configuration.KubeConfig = map[string]*string{}
+	Configuration.ImpersonateServiceAccount = map[string]*string{}
+	for _, controller := range Controllers {
+		Configuration.KubeConfig[controller] = flags.String(controller+"-kubeconfig", "", fmt.Sprintf("Absolute path to the kubeconfig file the %s controller uses instead of --kubeconfig, so that it only has its own permissions.", controller))
+		Configuration.ImpersonateServiceAccount[controller] = flags.String(controller+"-impersonate-service-account", "", fmt.Sprintf("ServiceAccount the %s controller impersonates, as <namespace>/<name>, so that it only has the permissions of the ServiceAccount. The identity of the process needs the impersonate permission on it.", controller))
+	}
+	flags.Float64Var(&Configuration.KubeAPIMaxQPS, "kube-api-max-qps", 0, "QPS of all the controllers together to the Kubernetes API server, on top of the QPS of each controller. 0 doesn't limit them.")
+	flags.IntVar(&Configuration.KubeAPIMaxBurst, "kube-api-max-burst", 0, "Burst of all the controllers together to the Kubernetes API server, 0 uses --kube-api-max-qps.")
+	Configuration.Tracing.AddFlags(flags)
+	flags.BoolVar(&Configuration.EnableAdminAPI, "enable-admin-api", false, "Serve the admin API under /admin/ on --http-endpoint, e.g. `POST /admin/controllers/attacher/pause` stops processing for a controller, a node or globally until resumed. Requires --http-endpoint-delegated-auth, requests are authorized as non-resource URLs.")
+	flags.StringVar(&Configuration.AdminSocket, "admin-socket", "", "If set, the diagnostics endpoints and the admin API are also served without authentication on a unix socket at this path, only accessible to the user of the process. Used by `csi-sidecars ctl --socket`.")
+}
+
+// RegisterAIOMetricsFlags registers the Kubernetes component metrics flags
+// like --disabled-metrics and --allow-metric-labels. These are pflag only.
+func RegisterAIOMetricsFlags(flags *pflag.FlagSet) {
+	Configuration.Metrics.AddFlags(flags)
 }
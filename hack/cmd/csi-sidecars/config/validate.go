@@ -0,0 +1,139 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubernetes-csi/csi-lib-utils/standardflags"
+	"github.com/kubernetes-csi/csi-sidecars/cmd/csi-sidecars/diagnostics"
+)
+
+// sharedAttacherFlags are the attacher flags every sidecar reads, they can
+// be set without enabling the attacher.
+var sharedAttacherFlags = []string{"worker-threads", "timeout"}
+
+// Validate returns every invalid flag value of the configuration, of the
+// common flags in standardflags.Configuration, of the metrics, tracing and
+// diagnostics server options and of the enabled sidecars. The feature gates
+// must be applied first.
+// set are the flags set on the command line, by environment variables or in
+// the --config file, the flags of the controllers that aren't enabled must
+// not be set.
+func (c *AIOConfiguration) Validate(set map[string]bool) error {
+	var errs []error
+
+	enabled := map[string]bool{}
+	for _, controller := range strings.Split(c.Controllers, ",") {
+		if controller == "" {
+			continue
+		}
+		if !slices.Contains(Controllers, controller) {
+			errs = append(errs, fmt.Errorf("--controllers has the unknown controller %q, the possible values are: [%s]", controller, strings.Join(Controllers, ",")))
+			continue
+		}
+		enabled[controller] = true
+	}
+	if c.Controllers == "" {
+		errs = append(errs, errors.New("--controllers must enable at least one controller"))
+	}
+
+	for _, d := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"resync", c.Resync},
+		{"retry-interval-start", c.RetryIntervalStart},
+		{"retry-interval-max", c.RetryIntervalMax},
+		{"leader-election-lease-duration", standardflags.Configuration.LeaderElectionLeaseDuration},
+		{"leader-election-renew-deadline", standardflags.Configuration.LeaderElectionRenewDeadline},
+		{"leader-election-retry-period", standardflags.Configuration.LeaderElectionRetryPeriod},
+	} {
+		if d.value < 0 {
+			errs = append(errs, fmt.Errorf("--%s must not be negative", d.name))
+		}
+	}
+	if c.RetryIntervalStart > c.RetryIntervalMax {
+		errs = append(errs, errors.New("--retry-interval-start must not be greater than --retry-interval-max"))
+	}
+	if standardflags.Configuration.LeaderElection && standardflags.Configuration.LeaderElectionRenewDeadline >= standardflags.Configuration.LeaderElectionLeaseDuration {
+		errs = append(errs, errors.New("--leader-election-renew-deadline must be less than --leader-election-lease-duration"))
+	}
+	if standardflags.Configuration.KubeAPIBurst < 0 {
+		errs = append(errs, errors.New("--kube-api-burst must not be negative"))
+	}
+	for _, controller := range Controllers {
+		if burst := c.KubeAPIBurst[controller]; burst != nil && *burst < 0 {
+			errs = append(errs, fmt.Errorf("--%s-kube-api-burst must not be negative", controller))
+		}
+	}
+	if c.KubeAPIMaxBurst < 0 {
+		errs = append(errs, errors.New("--kube-api-max-burst must not be negative"))
+	}
+	for _, controller := range Controllers {
+		kubeconfig, serviceAccount := c.KubeConfig[controller], c.ImpersonateServiceAccount[controller]
+		if kubeconfig == nil || serviceAccount == nil || *serviceAccount == "" {
+			continue
+		}
+		if *kubeconfig != "" {
+			errs = append(errs, fmt.Errorf("only one of --%s-kubeconfig and --%s-impersonate-service-account can be set", controller, controller))
+		}
+		if namespace, name, ok := strings.Cut(*serviceAccount, "/"); !ok || namespace == "" || name == "" || strings.Contains(name, "/") {
+			errs = append(errs, fmt.Errorf("--%s-impersonate-service-account must be <namespace>/<name>, got %q", controller, *serviceAccount))
+		}
+	}
+
+	errs = append(errs, c.Metrics.Validate()...)
+	errs = append(errs, c.Tracing.Validate())
+	diagnosticsConfig := diagnostics.Config{
+		TLSCertFile:       c.TLSCertFile,
+		TLSPrivateKeyFile: c.TLSPrivateKeyFile,
+		ClientCAFile:      c.ClientCAFile,
+		DelegatedAuth:     c.HTTPEndpointDelegatedAuth,
+		AdminSocket:       c.AdminSocket,
+	}
+	errs = append(errs, diagnosticsConfig.Validate())
+	if standardflags.Configuration.MetricsAddress != "" && standardflags.Configuration.HttpEndpoint != "" {
+		errs = append(errs, errors.New("only one of --metrics-address and --http-endpoint can be set"))
+	}
+	if !slices.Contains([]string{"none", "warn", "fail"}, c.RBACCheck) {
+		errs = append(errs, fmt.Errorf("--rbac-check must be none, warn or fail, got %q", c.RBACCheck))
+	}
+	if c.EnableAdminAPI && !c.HTTPEndpointDelegatedAuth {
+		errs = append(errs, errors.New("--enable-admin-api requires --http-endpoint-delegated-auth"))
+	}
+	if c.EnableAdminAPI && standardflags.Configuration.MetricsAddress == "" && standardflags.Configuration.HttpEndpoint == "" {
+		errs = append(errs, errors.New("--enable-admin-api requires --http-endpoint or --metrics-address"))
+	}
+
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, controller := range Controllers {
+			setting, ok := strings.CutPrefix(name, controller+"-")
+			if !ok || enabled[controller] || len(enabled) == 0 {
+				continue
+			}
+			if controller == "attacher" && slices.Contains(sharedAttacherFlags, setting) {
+				continue
+			}
+			errs = append(errs, fmt.Errorf("--%s is set but the %s controller isn't enabled", name, controller))
+		}
+	}
+
+	// Every sidecar reads the attacher worker count and timeout.
+	errs = append(errs, c.AttacherConfiguration.Validate())
+	if enabled["provisioner"] {
+		errs = append(errs, c.ProvisionerConfiguration.Validate())
+	}
+	if enabled["resizer"] {
+		errs = append(errs, c.ResizerConfiguration.Validate())
+	}
+	return errors.Join(errs...)
+}
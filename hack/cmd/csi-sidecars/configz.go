@@ -0,0 +1,106 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+	"k8s.io/klog/v2"
+
+	"github.com/kubernetes-csi/csi-sidecars/cmd/csi-sidecars/config"
+)
+
+// standaloneFlags are the flags of the standalone sidecar, registered
+// unprefixed by registerSidecarFlags.
+var standaloneFlags = map[string]bool{}
+
+// attacherFlag returns the name of an attacher flag, the worker count and
+// the timeout of every sidecar are the attacher flags.
+func attacherFlag(standalone, name string) string {
+	if standalone == "attacher" {
+		return name
+	}
+	return "attacher-" + name
+}
+
+// sharedSettings are the settings every controller reads from the same flag,
+// see copyFlagsFromConfigToGlobalVars, e.g. the timeout and operationTimeout
+// of every sidecar are --attacher-timeout.
+func sharedSettings(standalone string) map[string]string {
+	return map[string]string{
+		"worker-threads":       attacherFlag(standalone, "worker-threads"),
+		"timeout":              attacherFlag(standalone, "timeout"),
+		"retry-interval-start": "retry-interval-start",
+		"retry-interval-max":   "retry-interval-max",
+		"resync":               "resync",
+	}
+}
+
+// resolveConfigz returns the effective configuration of the process and of
+// the enabled controllers.
+func resolveConfigz(flags *flag.FlagSet, standalone string) config.Configz {
+	owner := func(name string) (string, string) {
+		if standaloneFlags[name] {
+			return standalone, name
+		}
+		for _, controller := range config.Controllers {
+			if setting, ok := strings.CutPrefix(name, controller+"-"); ok {
+				return controller, setting
+			}
+		}
+		return "", ""
+	}
+	c := config.Resolve(flags, strings.Split(config.Configuration.Controllers, ","), owner, sharedSettings(standalone))
+	// A negative --<controller>-v logs with -v, a zero
+	// --<controller>-kube-api-qps uses --kube-api-qps.
+	for _, settings := range c.Controllers {
+		for setting, unset := range map[string]func(string) bool{
+			"v":              func(v string) bool { return strings.HasPrefix(v, "-") },
+			"kube-api-qps":   func(v string) bool { return v == "0" },
+			"kube-api-burst": func(v string) bool { return v == "0" },
+		} {
+			if v, ok := settings[setting]; ok && unset(v.Value) {
+				process := c.Process[setting]
+				settings[setting] = config.Value{Value: process.Value, Source: process.Source, Flag: setting}
+			}
+		}
+	}
+	return c
+}
+
+// configzHandler serves the effective configuration at config.ConfigzPath.
+func configzHandler(flags *flag.FlagSet, standalone string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resolveConfigz(flags, standalone)); err != nil {
+			klog.ErrorS(err, "Failed to write the configuration")
+		}
+	})
+}
+
+// logConfigz logs the effective configuration once at startup.
+func logConfigz(flags *flag.FlagSet, standalone string) {
+	data, err := json.Marshal(resolveConfigz(flags, standalone))
+	if err != nil {
+		klog.ErrorS(err, "Failed to encode the configuration")
+		return
+	}
+	klog.InfoS("Effective configuration", "configz", json.RawMessage(data))
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ctl
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// socketHost is the placeholder host of the requests sent on the admin socket.
+const socketHost = "csi-sidecars"
+
+// client calls the diagnostics endpoints and the admin API of one instance.
+type client struct {
+	base  string
+	token string
+	http  *http.Client
+}
+
+type clientOptions struct {
+	server             string
+	socket             string
+	token              string
+	tokenFile          string
+	insecureSkipVerify bool
+	timeout            time.Duration
+}
+
+func newClient(o clientOptions) (*client, error) {
+	if (o.server == "") == (o.socket == "") {
+		return nil, fmt.Errorf("exactly one of --server and --socket must be set")
+	}
+	token := o.token
+	if o.tokenFile != "" {
+		data, err := os.ReadFile(o.tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the token: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	c := &client{token: token, http: &http.Client{Transport: transport, Timeout: o.timeout}}
+	if o.socket != "" {
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", o.socket)
+		}
+		c.base = "http://" + socketHost
+		return c, nil
+	}
+	if o.insecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	u, err := url.Parse(o.server)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("--server must be a URL like http://localhost:8080, got %q", o.server)
+	}
+	c.base = strings.TrimSuffix(u.String(), "/")
+	return c, nil
+}
+
+// do sends a request and decodes the JSON response into out, if not nil.
+func (c *client) do(ctx context.Context, method, path string, query url.Values, out any) error {
+	u := c.base + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	if out == nil {
+		return nil
+	}
+	if s, ok := out.(*string); ok {
+		*s = string(body)
+		return nil
+	}
+	return json.Unmarshal(body, out)
+}
@@ -0,0 +1,364 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ctl implements `csi-sidecars ctl`, a command-line client of the
+// diagnostics endpoints and the admin API of one AIO instance. It connects
+// to --http-endpoint, e.g. through `kubectl port-forward`, or to the local
+// --admin-socket, e.g. with `kubectl exec`.
+package ctl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kubernetes-csi/csi-sidecars/cmd/csi-sidecars/diagnostics"
+	"github.com/kubernetes-csi/csi-sidecars/pkg/admin"
+	"github.com/kubernetes-csi/csi-sidecars/pkg/operations"
+	"github.com/kubernetes-csi/csi-sidecars/pkg/queues"
+)
+
+// errUnhealthy makes `ctl health` exit with 1 in every output format.
+var errUnhealthy = errors.New("the instance is unhealthy")
+
+// Output formats of -o.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+const usage = `Usage: csi-sidecars ctl [flags] <command> [args]
+
+Commands:
+  controllers                           List the controllers, their leader status and health.
+  health                                Show the verbose /healthz report.
+  queues [--controller c] [--queue q]   List the workqueues and their items.
+  volume <pv-name|volume-handle>        Show the recent CSI RPCs of a volume.
+  pauses                                List the active pauses.
+  pause [--controller c | --node n]     Pause processing globally, for a controller or for a node.
+  resume [--controller c | --node n]    Resume processing.
+  requeue <controller> <kind> [<namespace>/]<name>
+                                        Add an object to the queues of a controller right away.
+  resync <controller>                   Add every object to the queues of a controller.
+
+Flags:
+`
+
+type command struct {
+	client *client
+	output string
+	out    io.Writer
+}
+
+// Run runs `csi-sidecars ctl` with args, the arguments after `ctl`, and
+// returns the exit code.
+func Run(args []string) int {
+	flags := flag.NewFlagSet("csi-sidecars ctl", flag.ContinueOnError)
+	var o clientOptions
+	flags.StringVar(&o.server, "server", "", "URL of --http-endpoint, e.g. http://localhost:8080 when port-forwarded.")
+	flags.StringVar(&o.socket, "socket", "", "Path of --admin-socket.")
+	flags.StringVar(&o.token, "token", "", "Bearer token sent to --server, e.g. with --http-endpoint-delegated-auth.")
+	flags.StringVar(&o.tokenFile, "token-file", "", "File containing the bearer token sent to --server.")
+	flags.BoolVar(&o.insecureSkipVerify, "insecure-skip-tls-verify", false, "Don't verify the serving certificate of --server.")
+	flags.DurationVar(&o.timeout, "timeout", 30*time.Second, "Timeout of every request.")
+	output := flags.String("o", outputTable, "Output format, table or json.")
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+	if *output != outputTable && *output != outputJSON {
+		fmt.Fprintf(os.Stderr, "unknown output format %q\n", *output)
+		return 2
+	}
+	c, err := newClient(o)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	cmd := &command{client: c, output: *output, out: os.Stdout}
+	ctx := context.Background()
+	name, cmdArgs := flags.Arg(0), flags.Args()[1:]
+	switch name {
+	case "controllers":
+		err = cmd.controllers(ctx)
+	case "health":
+		err = cmd.health(ctx)
+	case "queues":
+		err = cmd.queues(ctx, cmdArgs)
+	case "volume":
+		err = cmd.volume(ctx, cmdArgs)
+	case "pauses":
+		err = cmd.pauses(ctx, http.MethodGet, "/admin/pauses")
+	case "pause", "resume":
+		err = cmd.setPaused(ctx, name, cmdArgs)
+	case "requeue":
+		err = cmd.requeue(ctx, cmdArgs)
+	case "resync":
+		err = cmd.resync(ctx, cmdArgs)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", name)
+		flags.Usage()
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func (c *command) controllers(ctx context.Context) error {
+	var statuses []diagnostics.ControllerStatus
+	if err := c.client.do(ctx, http.MethodGet, diagnostics.ControllersPath, nil, &statuses); err != nil {
+		return err
+	}
+	if c.output == outputJSON {
+		return c.json(statuses)
+	}
+	return c.table([]string{"NAME", "MOUNTED", "RUNNING", "SINCE", "LEADER ELECTION"}, len(statuses), func(i int) []string {
+		s := statuses[i]
+		since := ""
+		if s.RunningSince != nil {
+			since = age(*s.RunningSince)
+		}
+		leaderElection := s.LeaderElection
+		if leaderElection == "" {
+			leaderElection = "disabled"
+		}
+		return []string{s.Name, strconv.FormatBool(s.Mounted), strconv.FormatBool(s.Running), since, leaderElection}
+	})
+}
+
+func (c *command) health(ctx context.Context) error {
+	var report string
+	err := c.client.do(ctx, http.MethodGet, "/healthz", url.Values{"verbose": {""}}, &report)
+	if c.output == outputJSON {
+		// The report is only available as text, wrap it.
+		healthy := err == nil
+		if err != nil {
+			report = err.Error()
+		}
+		if err := c.json(map[string]any{"healthy": healthy, "report": report}); err != nil {
+			return err
+		}
+		if !healthy {
+			return errUnhealthy
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprint(c.out, report)
+	return err
+}
+
+func (c *command) queues(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("queues", flag.ContinueOnError)
+	controller := flags.String("controller", "", "Only list the queues of this controller.")
+	queue := flags.String("queue", "", "Only list the queue with this name, e.g. vaQueue.")
+	limit := flags.Int("limit", 0, "Maximum number of items to list, all items are listed when 0.")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var page queues.Page
+	query := url.Values{}
+	if *controller != "" {
+		query.Set("controller", *controller)
+	}
+	if *queue != "" {
+		query.Set("queue", *queue)
+	}
+	for {
+		var next queues.Page
+		if err := c.client.do(ctx, http.MethodGet, queues.DebugPath, query, &next); err != nil {
+			return err
+		}
+		page.Queues = next.Queues
+		page.Unregistered = next.Unregistered
+		page.Items = append(page.Items, next.Items...)
+		if *limit > 0 && len(page.Items) >= *limit {
+			page.Items = page.Items[:*limit]
+			break
+		}
+		if next.Continue == "" {
+			break
+		}
+		query.Set("continue", next.Continue)
+	}
+
+	if c.output == outputJSON {
+		return c.json(page)
+	}
+	err := c.table([]string{"CONTROLLER", "QUEUE", "KIND", "DEPTH", "DELAYED", "PROCESSING", "REQUEUES"}, len(page.Queues), func(i int) []string {
+		q := page.Queues[i]
+		return []string{q.Controller, q.Queue, q.Kind, strconv.Itoa(q.Depth), strconv.Itoa(q.Delayed), strconv.Itoa(q.Processing), strconv.Itoa(q.Requeues)}
+	})
+	if err != nil {
+		return err
+	}
+	for _, controller := range slices.Sorted(maps.Keys(page.Unregistered)) {
+		fmt.Fprintf(c.out, "The queues of %s aren't registered: %s\n", controller, page.Unregistered[controller])
+	}
+	if len(page.Items) == 0 {
+		return nil
+	}
+	fmt.Fprintln(c.out)
+	return c.table([]string{"CONTROLLER", "QUEUE", "KEY", "STATE", "WAITING", "REQUEUES", "BACKOFF"}, len(page.Items), func(i int) []string {
+		item := page.Items[i]
+		return []string{item.Controller, item.Queue, item.Key, item.State, item.Waiting, strconv.Itoa(item.Requeues), item.Backoff}
+	})
+}
+
+func (c *command) volume(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: volume <pv-name|volume-handle>")
+	}
+	var records []operations.Record
+	if err := c.client.do(ctx, http.MethodGet, operations.VolumePathPrefix+url.PathEscape(args[0]), nil, &records); err != nil {
+		return err
+	}
+	if c.output == outputJSON {
+		return c.json(records)
+	}
+	return c.table([]string{"TIME", "CONTROLLER", "OBJECT", "RPC", "LATENCY", "CODE", "RETRIES", "OPERATION"}, len(records), func(i int) []string {
+		r := records[i]
+		return []string{r.Time.Format(time.RFC3339), r.Controller, r.Object, r.RPC, r.Latency, r.Code, strconv.Itoa(r.Retries), r.OperationID}
+	})
+}
+
+func (c *command) pauses(ctx context.Context, method, path string) error {
+	var pauses []admin.Pause
+	if err := c.client.do(ctx, method, path, nil, &pauses); err != nil {
+		return err
+	}
+	if c.output == outputJSON {
+		return c.json(pauses)
+	}
+	return c.table([]string{"SCOPE", "NAME", "SINCE"}, len(pauses), func(i int) []string {
+		p := pauses[i]
+		return []string{p.Scope, p.Name, age(p.Since)}
+	})
+}
+
+func (c *command) setPaused(ctx context.Context, action string, args []string) error {
+	flags := flag.NewFlagSet(action, flag.ContinueOnError)
+	controller := flags.String("controller", "", "The controller to "+action+".")
+	node := flags.String("node", "", "The node to "+action+" the processing of objects for.")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	var path string
+	switch {
+	case *controller != "" && *node != "":
+		return fmt.Errorf("--controller and --node are mutually exclusive")
+	case *controller != "":
+		path = "/admin/controllers/" + url.PathEscape(*controller) + "/" + action
+	case *node != "":
+		path = "/admin/nodes/" + url.PathEscape(*node) + "/" + action
+	default:
+		path = "/admin/" + action
+	}
+	return c.pauses(ctx, http.MethodPost, path)
+}
+
+func (c *command) requeue(ctx context.Context, args []string) error {
+	if len(args) != 3 {
+		return fmt.Errorf("usage: requeue <controller> <kind> [<namespace>/]<name>")
+	}
+	query := url.Values{"kind": {args[1]}}
+	if namespace, name, ok := strings.Cut(args[2], "/"); ok {
+		query.Set("namespace", namespace)
+		query.Set("name", name)
+	} else {
+		query.Set("name", args[2])
+	}
+	return c.enqueued(ctx, "/admin/controllers/"+url.PathEscape(args[0])+"/requeue", query)
+}
+
+func (c *command) resync(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: resync <controller>")
+	}
+	return c.enqueued(ctx, "/admin/controllers/"+url.PathEscape(args[0])+"/resync", nil)
+}
+
+func (c *command) enqueued(ctx context.Context, path string, query url.Values) error {
+	var enqueued []admin.Enqueued
+	if err := c.client.do(ctx, http.MethodPost, path, query, &enqueued); err != nil {
+		return err
+	}
+	if c.output == outputJSON {
+		return c.json(enqueued)
+	}
+	return c.table([]string{"CONTROLLER", "QUEUE", "KIND", "ITEMS"}, len(enqueued), func(i int) []string {
+		e := enqueued[i]
+		return []string{e.Controller, e.Queue, e.Kind, strconv.Itoa(e.Items)}
+	})
+}
+
+func (c *command) json(v any) error {
+	enc := json.NewEncoder(c.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func (c *command) table(header []string, rows int, row func(i int) []string) error {
+	w := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for i := 0; i < rows; i++ {
+		fmt.Fprintln(w, strings.Join(row(i), "\t"))
+	}
+	return w.Flush()
+}
+
+// age formats the time elapsed since t like kubectl does, e.g. 5m.
+func age(t time.Time) string {
+	d := time.Since(t).Round(time.Second)
+	switch {
+	case d < time.Minute:
+		return d.String()
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
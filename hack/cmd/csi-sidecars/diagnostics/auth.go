@@ -0,0 +1,77 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnostics
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"k8s.io/apiserver/pkg/authentication/authenticatorfactory"
+	"k8s.io/apiserver/pkg/authorization/authorizerfactory"
+	genericapifilters "k8s.io/apiserver/pkg/endpoints/filters"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/server/dynamiccertificates"
+	"k8s.io/apiserver/pkg/server/options"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// The same cache TTLs the Kubernetes components use for delegated
+// authentication and authorization.
+const (
+	tokenReviewCacheTTL        = 10 * time.Second
+	tokenReviewTimeout         = 10 * time.Second
+	subjectAccessAllowCacheTTL = 10 * time.Second
+	subjectAccessDenyCacheTTL  = 10 * time.Second
+)
+
+// withDelegatedAuth wraps handler with the authentication and authorization
+// filters of the Kubernetes components. Bearer tokens are verified with
+// TokenReview, client certificates with config.ClientCAFile, and the request
+// path and verb are authorized with a non-resource SubjectAccessReview, e.g.
+// `get` on `/metrics`.
+func withDelegatedAuth(handler http.Handler, config Config, clientCA dynamiccertificates.CAContentProvider) (http.Handler, error) {
+	authnConfig := authenticatorfactory.DelegatingAuthenticatorConfig{
+		TokenAccessReviewClient:  config.Client.AuthenticationV1(),
+		TokenAccessReviewTimeout: tokenReviewTimeout,
+		WebhookRetryBackoff:      options.DefaultAuthWebhookRetryBackoff(),
+		CacheTTL:                 tokenReviewCacheTTL,
+	}
+	if clientCA != nil {
+		authnConfig.ClientCertificateCAContentProvider = clientCA
+	}
+	authenticator, _, _, err := authnConfig.New()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the delegated authenticator: %w", err)
+	}
+
+	authzConfig := authorizerfactory.DelegatingAuthorizerConfig{
+		SubjectAccessReviewClient: config.Client.AuthorizationV1(),
+		AllowCacheTTL:             subjectAccessAllowCacheTTL,
+		DenyCacheTTL:              subjectAccessDenyCacheTTL,
+		WebhookRetryBackoff:       options.DefaultAuthWebhookRetryBackoff(),
+	}
+	authorizer, err := authzConfig.New()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the delegated authorizer: %w", err)
+	}
+
+	handler = genericapifilters.WithAuthorization(handler, authorizer, scheme.Codecs)
+	handler = genericapifilters.WithAuthentication(handler, authenticator, genericapifilters.Unauthorized(scheme.Codecs), nil, nil)
+	handler = genericapifilters.WithRequestInfo(handler, &apirequest.RequestInfoFactory{})
+	return handler, nil
+}
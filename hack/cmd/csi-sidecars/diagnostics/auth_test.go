@@ -0,0 +1,143 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnostics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const (
+	allowedToken = "allowed-token"
+	deniedToken  = "denied-token"
+)
+
+// fakeAuthClient authenticates allowedToken as the user "allowed" and
+// deniedToken as the user "denied", and only authorizes the user "allowed"
+// to get /metrics.
+func fakeAuthClient() *fake.Clientset {
+	client := fake.NewClientset()
+	client.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview).DeepCopy()
+		switch review.Spec.Token {
+		case allowedToken:
+			review.Status = authenticationv1.TokenReviewStatus{Authenticated: true, User: authenticationv1.UserInfo{Username: "allowed"}}
+		case deniedToken:
+			review.Status = authenticationv1.TokenReviewStatus{Authenticated: true, User: authenticationv1.UserInfo{Username: "denied"}}
+		default:
+			review.Status = authenticationv1.TokenReviewStatus{Authenticated: false}
+		}
+		return true, review, nil
+	})
+	client.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview).DeepCopy()
+		attrs := review.Spec.NonResourceAttributes
+		review.Status.Allowed = review.Spec.User == "allowed" && attrs != nil && attrs.Path == "/metrics" && attrs.Verb == "get"
+		return true, review, nil
+	})
+	return client
+}
+
+func TestDelegatedAuth(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		token          string
+		expectedStatus int
+	}{
+		{
+			name:           "allowed",
+			path:           "/metrics",
+			token:          allowedToken,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "paths under the sidecar prefix are authorized on their own",
+			path:           "/attacher/metrics",
+			token:          allowedToken,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "not authorized for the path",
+			path:           ControllersPath,
+			token:          allowedToken,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "not authorized",
+			path:           "/metrics",
+			token:          deniedToken,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "invalid token",
+			path:           "/metrics",
+			token:          "invalid-token",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "anonymous",
+			path:           "/metrics",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "anonymous healthz",
+			path:           "/healthz",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "anonymous healthz under the sidecar prefix",
+			path:           "/attacher/healthz",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	s, err := NewServer(Config{
+		DelegatedAuth: true,
+		Client:        fakeAuthClient(),
+		Controllers:   []string{"attacher"},
+	})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("metrics"))
+	})
+	mount(s, "attacher", mux)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			if test.token != "" {
+				req.Header.Set("Authorization", "Bearer "+test.token)
+			}
+			rec := httptest.NewRecorder()
+			s.serveHTTP(rec, req)
+			if rec.Code != test.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", test.expectedStatus, rec.Code, rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnostics
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// ControllersPath serves the status of every enabled sidecar.
+const ControllersPath = "/debug/controllers"
+
+// ControllerStatus is the status of a sidecar.
+type ControllerStatus struct {
+	Name string `json:"name"`
+	// Mounted is set once the sidecar serves its handlers on the server.
+	Mounted bool `json:"mounted"`
+	// Running is set while the sidecar runs its controllers, i.e. while it
+	// holds its leader election lease or always when leader election is
+	// disabled.
+	Running      bool       `json:"running"`
+	RunningSince *time.Time `json:"runningSince,omitempty"`
+	// LeaderElection is the result of the leader election health check,
+	// "ok" or the error. It's empty when leader election is disabled.
+	LeaderElection string `json:"leaderElection,omitempty"`
+}
+
+// MarkRunning records that the controllers of a sidecar started running,
+// the returned function records that they stopped.
+func (s *Server) MarkRunning(name string) func() {
+	s.controllersMu.Lock()
+	s.running[name] = time.Now()
+	s.controllersMu.Unlock()
+	return func() {
+		s.controllersMu.Lock()
+		delete(s.running, name)
+		s.controllersMu.Unlock()
+	}
+}
+
+// ControllerStatuses returns the status of every enabled sidecar.
+func (s *Server) ControllerStatuses(r *http.Request) []ControllerStatus {
+	s.controllersMu.RLock()
+	statuses := make([]ControllerStatus, 0, len(s.config.Controllers))
+	for _, name := range s.config.Controllers {
+		status := ControllerStatus{Name: name}
+		for _, c := range s.controllers {
+			if c.name == name {
+				status.Mounted = true
+			}
+		}
+		if since, ok := s.running[name]; ok {
+			status.Running = true
+			status.RunningSince = &since
+		}
+		statuses = append(statuses, status)
+	}
+	s.controllersMu.RUnlock()
+
+	for _, check := range s.healthChecks() {
+		for i := range statuses {
+			if check.name != statuses[i].Name+"-leader-election" {
+				continue
+			}
+			if err := check.check(r); err != nil {
+				statuses[i].LeaderElection = err.Error()
+			} else {
+				statuses[i].LeaderElection = "ok"
+			}
+		}
+	}
+	return statuses
+}
+
+func (s *Server) serveControllers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(s.ControllerStatuses(r))
+}
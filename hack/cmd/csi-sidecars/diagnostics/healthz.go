@@ -0,0 +1,122 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnostics
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+)
+
+// leaderElectionHealthzPath is where leaderelection.PrepareHealthCheck
+// registers the leader election health check on the mux of a sidecar.
+const leaderElectionHealthzPath = "/healthz/leader-election"
+
+type healthCheck struct {
+	name  string
+	check func(r *http.Request) error
+	// detail, if set, describes the state behind a passing check.
+	detail func() string
+}
+
+// AddHealthCheck adds a named check to /healthz.
+func (s *Server) AddHealthCheck(name string, check func(r *http.Request) error) {
+	s.checksMu.Lock()
+	defer s.checksMu.Unlock()
+	s.checks = append(s.checks, healthCheck{name: name, check: check})
+}
+
+// AddHealthDetail adds an informational entry to /healthz that always
+// passes, detail is reported next to it when not empty, e.g. the paused
+// controllers.
+func (s *Server) AddHealthDetail(name string, detail func() string) {
+	s.checksMu.Lock()
+	defer s.checksMu.Unlock()
+	s.checks = append(s.checks, healthCheck{
+		name:   name,
+		check:  func(*http.Request) error { return nil },
+		detail: detail,
+	})
+}
+
+// healthChecks returns the checks added with AddHealthCheck followed by the
+// leader election check of every sidecar that registered one.
+func (s *Server) healthChecks() []healthCheck {
+	s.checksMu.RLock()
+	checks := append([]healthCheck(nil), s.checks...)
+	s.checksMu.RUnlock()
+
+	for _, c := range s.controllerMuxes() {
+		req, _ := http.NewRequest(http.MethodGet, leaderElectionHealthzPath, nil)
+		h, pattern := c.mux.Handler(req)
+		if pattern == "" {
+			continue
+		}
+		checks = append(checks, healthCheck{
+			name: c.name + "-leader-election",
+			check: func(r *http.Request) error {
+				rec := httptest.NewRecorder()
+				h.ServeHTTP(rec, r.Clone(r.Context()))
+				if rec.Code != http.StatusOK {
+					return fmt.Errorf("%s", bytes.TrimSpace(rec.Body.Bytes()))
+				}
+				return nil
+			},
+		})
+	}
+	return checks
+}
+
+func (c healthCheck) details() string {
+	if c.detail == nil {
+		return ""
+	}
+	return c.detail()
+}
+
+// serveHealthz runs every check and reports them in the same format as the
+// Kubernetes components, details are included on failure or with ?verbose.
+func (s *Server) serveHealthz(w http.ResponseWriter, r *http.Request) {
+	var out bytes.Buffer
+	failed := false
+	for _, c := range s.healthChecks() {
+		if err := c.check(r); err != nil {
+			fmt.Fprintf(&out, "[-]%s failed: %v\n", c.name, err)
+			failed = true
+		} else if detail := c.details(); detail != "" {
+			fmt.Fprintf(&out, "[+]%s ok: %s\n", c.name, detail)
+		} else {
+			fmt.Fprintf(&out, "[+]%s ok\n", c.name)
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if failed {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(&out, "healthz check failed\n")
+		_, _ = out.WriteTo(w)
+		return
+	}
+	if _, verbose := r.URL.Query()["verbose"]; verbose {
+		fmt.Fprintf(&out, "healthz check passed\n")
+		_, _ = out.WriteTo(w)
+		return
+	}
+	fmt.Fprint(w, "ok")
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnostics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// mount mounts mux like ServeController does, without blocking.
+func mount(s *Server, name string, mux *http.ServeMux) {
+	s.controllersMu.Lock()
+	defer s.controllersMu.Unlock()
+	s.controllers = append(s.controllers, controllerMux{name: name, mux: mux})
+}
+
+// leaderElectionMux returns the mux of a sidecar whose leader election
+// health check returns err.
+func leaderElectionMux(err error) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc(leaderElectionHealthzPath, func(w http.ResponseWriter, r *http.Request) {
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+	return mux
+}
+
+func TestHealthz(t *testing.T) {
+	tests := []struct {
+		name           string
+		checkErr       error
+		attacherErr    error
+		path           string
+		expectedStatus int
+		expectedBody   []string
+		unexpectedBody []string
+	}{
+		{
+			name:           "all checks pass",
+			path:           "/healthz",
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{"ok"},
+			unexpectedBody: []string{"[+]"},
+		},
+		{
+			name:           "verbose",
+			path:           "/healthz?verbose",
+			expectedStatus: http.StatusOK,
+			expectedBody: []string{
+				"[+]csi ok\n",
+				"[+]paused ok: controller/attacher\n",
+				"[+]attacher-leader-election ok\n",
+				"[+]provisioner-leader-election ok\n",
+				"healthz check passed\n",
+			},
+		},
+		{
+			name:           "failed check",
+			checkErr:       errors.New("driver not ready"),
+			path:           "/healthz",
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody: []string{
+				"[-]csi failed: driver not ready\n",
+				"[+]attacher-leader-election ok\n",
+				"healthz check failed\n",
+			},
+		},
+		{
+			name:           "failed leader election of one sidecar",
+			attacherErr:    errors.New("lease expired"),
+			path:           "/healthz",
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody: []string{
+				"[+]csi ok\n",
+				"[-]attacher-leader-election failed: lease expired\n",
+				"[+]provisioner-leader-election ok\n",
+			},
+		},
+		{
+			name:           "served under the sidecar prefix",
+			attacherErr:    errors.New("lease expired"),
+			path:           "/provisioner/healthz",
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   []string{"[-]attacher-leader-election failed: lease expired\n"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, err := NewServer(Config{Controllers: []string{"attacher", "provisioner", "resizer"}})
+			if err != nil {
+				t.Fatalf("NewServer failed: %v", err)
+			}
+			s.AddHealthCheck("csi", func(*http.Request) error { return test.checkErr })
+			s.AddHealthDetail("paused", func() string { return "controller/attacher" })
+			mount(s, "attacher", leaderElectionMux(test.attacherErr))
+			mount(s, "provisioner", leaderElectionMux(nil))
+			// The resizer runs without leader election.
+			mount(s, "resizer", http.NewServeMux())
+
+			rec := httptest.NewRecorder()
+			s.serveHTTP(rec, httptest.NewRequest(http.MethodGet, test.path, nil))
+
+			if rec.Code != test.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", test.expectedStatus, rec.Code, rec.Body)
+			}
+			body := rec.Body.String()
+			for _, expected := range test.expectedBody {
+				if !strings.Contains(body, expected) {
+					t.Errorf("expected %q in the response, got:\n%s", expected, body)
+				}
+			}
+			for _, unexpected := range test.unexpectedBody {
+				if strings.Contains(body, unexpected) {
+					t.Errorf("unexpected %q in the response, got:\n%s", unexpected, body)
+				}
+			}
+			if strings.Contains(body, "resizer-leader-election") {
+				t.Errorf("unexpected leader election check of the resizer, got:\n%s", body)
+			}
+		})
+	}
+}
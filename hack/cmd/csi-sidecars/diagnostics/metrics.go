@@ -0,0 +1,75 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnostics
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// ControllerLabel is the label the metrics of every sidecar get on the
+// unprefixed metrics path, e.g. csi_sidecar_operations_seconds of the
+// attacher and of the provisioner.
+const ControllerLabel = "controller"
+
+// controllerGatherer adds the controller label to every metric of the
+// registry of a sidecar.
+type controllerGatherer struct {
+	controller string
+	gatherer   prometheus.Gatherer
+}
+
+// Gather implements prometheus.Gatherer.
+func (g controllerGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := g.gatherer.Gather()
+	for _, family := range families {
+		for _, metric := range family.Metric {
+			name, value := ControllerLabel, g.controller
+			metric.Label = append(metric.Label, &dto.LabelPair{Name: &name, Value: &value})
+			sort.Slice(metric.Label, func(i, j int) bool {
+				return metric.Label[i].GetName() < metric.Label[j].GetName()
+			})
+		}
+	}
+	return families, err
+}
+
+// gatherer returns the metrics of every mounted sidecar, labeled with the
+// sidecar, and the metrics of the process.
+func (s *Server) gatherer() prometheus.Gatherer {
+	var gatherers prometheus.Gatherers
+	if s.config.Gatherer != nil {
+		gatherers = append(gatherers, s.config.Gatherer)
+	}
+	for _, c := range s.controllerMuxes() {
+		if c.gatherer != nil {
+			gatherers = append(gatherers, controllerGatherer{controller: c.name, gatherer: c.gatherer})
+		}
+	}
+	return gatherers
+}
+
+// serveMetrics serves the metrics of every sidecar on the unprefixed
+// metrics path, so that a single scrape of the AIO binary gets them all.
+// /<controller>/<metrics path> keeps serving the metrics of one sidecar.
+func (s *Server) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	promhttp.HandlerFor(s.gatherer(), promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError}).ServeHTTP(w, r)
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnostics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// sidecarRegistry returns the registry of a sidecar that called Probe once,
+// and its mux serving it on /metrics like the CSIMetricsManager does.
+func sidecarRegistry() (*prometheus.Registry, *http.ServeMux) {
+	registry := prometheus.NewRegistry()
+	operations := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "csi_sidecar_operations_total",
+		Help: "Number of CSI operations.",
+	}, []string{"method_name"})
+	registry.MustRegister(operations)
+	operations.WithLabelValues("/csi.v1.Identity/Probe").Inc()
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	return registry, mux
+}
+
+func TestMetrics(t *testing.T) {
+	process := prometheus.NewRegistry()
+	process.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "process_test",
+		Help: "A metric of the process.",
+	}, func() float64 { return 1 }))
+
+	s, err := NewServer(Config{
+		Controllers: []string{"attacher", "provisioner"},
+		MetricsPath: "/metrics",
+		Gatherer:    process,
+	})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	for _, name := range []string{"attacher", "provisioner"} {
+		registry, mux := sidecarRegistry()
+		s.controllers = append(s.controllers, controllerMux{name: name, mux: mux, gatherer: registry})
+	}
+
+	tests := []struct {
+		path           string
+		expectedBody   []string
+		unexpectedBody []string
+	}{
+		{
+			path: "/metrics",
+			expectedBody: []string{
+				`csi_sidecar_operations_total{controller="attacher",method_name="/csi.v1.Identity/Probe"} 1`,
+				`csi_sidecar_operations_total{controller="provisioner",method_name="/csi.v1.Identity/Probe"} 1`,
+				"process_test 1",
+			},
+		},
+		{
+			path:           "/attacher/metrics",
+			expectedBody:   []string{`csi_sidecar_operations_total{method_name="/csi.v1.Identity/Probe"} 1`},
+			unexpectedBody: []string{"controller=", "process_test"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.serveHTTP(rec, httptest.NewRequest(http.MethodGet, test.path, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+			}
+			body := rec.Body.String()
+			for _, expected := range test.expectedBody {
+				if !strings.Contains(body, expected) {
+					t.Errorf("expected %q in the response, got:\n%s", expected, body)
+				}
+			}
+			for _, unexpected := range test.unexpectedBody {
+				if strings.Contains(body, unexpected) {
+					t.Errorf("unexpected %q in the response, got:\n%s", unexpected, body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,410 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnostics
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apiserver/pkg/server/dynamiccertificates"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+const shutdownTimeout = 5 * time.Second
+
+// Config configures the diagnostics HTTP server shared by every sidecar
+// running in the AIO binary.
+type Config struct {
+	// Address is the TCP network address the server listens on, the value
+	// of --http-endpoint or --metrics-address.
+	Address string
+
+	// TLSCertFile and TLSPrivateKeyFile enable HTTPS. Both files are watched
+	// and reloaded when they change.
+	TLSCertFile       string
+	TLSPrivateKeyFile string
+	// ClientCAFile enables client certificate authentication, it requires
+	// DelegatedAuth.
+	ClientCAFile string
+
+	// DelegatedAuth authenticates requests with TokenReview and authorizes
+	// them with SubjectAccessReview. /healthz is always served anonymously.
+	DelegatedAuth bool
+	// Client is used for the TokenReview and SubjectAccessReview calls.
+	Client kubernetes.Interface
+
+	// AdminSocket is the path of a unix socket the server also listens on,
+	// the value of --admin-socket. Requests on it aren't authenticated, the
+	// socket is only accessible to the user of the process.
+	AdminSocket string
+
+	// Controllers are the sidecars enabled with --controllers.
+	Controllers []string
+
+	// MetricsPath is the path the metrics of every sidecar are served on
+	// together, the value of --metrics-path. The metrics of one sidecar
+	// are served on /<controller>/<MetricsPath>.
+	MetricsPath string
+	// Gatherer gathers the metrics that aren't specific to a sidecar,
+	// e.g. the process and Go runtime metrics.
+	Gatherer prometheus.Gatherer
+}
+
+// Validate checks that the flags of the configuration are consistent, it
+// doesn't need Client.
+func (c *Config) Validate() error {
+	var errs []error
+	if (c.TLSCertFile == "") != (c.TLSPrivateKeyFile == "") {
+		errs = append(errs, errors.New("--tls-cert-file and --tls-private-key-file must be set together"))
+	}
+	if c.ClientCAFile != "" && c.TLSCertFile == "" {
+		errs = append(errs, errors.New("--client-ca-file requires --tls-cert-file and --tls-private-key-file"))
+	}
+	if c.ClientCAFile != "" && !c.DelegatedAuth {
+		errs = append(errs, errors.New("--client-ca-file requires --http-endpoint-delegated-auth"))
+	}
+	return errors.Join(errs...)
+}
+
+// controllerMux is the mux a sidecar registered its own handlers on,
+// e.g. metrics, pprof and the leader election health check.
+type controllerMux struct {
+	name string
+	mux  *http.ServeMux
+	// gatherer gathers the metrics of the sidecar, nil if it has none.
+	gatherer prometheus.Gatherer
+}
+
+// Server is the single diagnostics HTTP server of the AIO binary.
+//
+// Every sidecar keeps registering its handlers on its own http.ServeMux and
+// mounts it with ServeController. A request is routed to, in order:
+//   - the handlers registered by the AIO binary with Handle, including the
+//     metrics of every sidecar on the metrics path, e.g. /metrics,
+//   - the sidecar named by the first path segment, e.g. /attacher/metrics,
+//   - the first sidecar that handles the path, e.g. /debug/pprof/.
+//
+// Handlers registered with HandleLocal are only served on the admin socket.
+type Server struct {
+	config Config
+
+	mux      *http.ServeMux
+	localMux *http.ServeMux
+	handler  http.Handler
+	clientCA *dynamiccertificates.DynamicFileCAContent
+	checksMu sync.RWMutex
+	checks   []healthCheck
+
+	controllersMu sync.RWMutex
+	controllers   []controllerMux
+	running       map[string]time.Time
+
+	done chan struct{}
+	err  error
+}
+
+// NewServer validates config and creates a Server, it doesn't start
+// listening until Run is called.
+func NewServer(config Config) (*Server, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if config.DelegatedAuth && config.Client == nil {
+		return nil, errors.New("--http-endpoint-delegated-auth requires a Kubernetes client")
+	}
+	s := &Server{
+		config:   config,
+		mux:      http.NewServeMux(),
+		localMux: http.NewServeMux(),
+		running:  map[string]time.Time{},
+		done:     make(chan struct{}),
+	}
+	s.mux.HandleFunc("/healthz", s.serveHealthz)
+	s.mux.HandleFunc(ControllersPath, s.serveControllers)
+	if config.MetricsPath != "" {
+		s.mux.HandleFunc(config.MetricsPath, s.serveMetrics)
+	}
+
+	var handler http.Handler = http.HandlerFunc(s.route)
+	if config.DelegatedAuth {
+		var clientCA dynamiccertificates.CAContentProvider
+		if config.ClientCAFile != "" {
+			var err error
+			s.clientCA, err = dynamiccertificates.NewDynamicCAContentFromFile("client-ca", config.ClientCAFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load the client CA: %w", err)
+			}
+			clientCA = s.clientCA
+		}
+		var err error
+		handler, err = withDelegatedAuth(handler, config, clientCA)
+		if err != nil {
+			return nil, err
+		}
+	}
+	s.handler = handler
+	return s, nil
+}
+
+// Handle registers a handler owned by the AIO binary.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// HandleLocal registers a handler owned by the AIO binary that is only
+// served on the admin socket.
+func (s *Server) HandleLocal(pattern string, handler http.Handler) {
+	s.localMux.Handle(pattern, handler)
+}
+
+// ServeController mounts the mux of a sidecar on the shared server and
+// blocks until the server stops, like http.ListenAndServe does. gatherer
+// gathers the metrics of the sidecar, they're served with the other
+// sidecars' on the metrics path.
+func (s *Server) ServeController(name string, mux *http.ServeMux, gatherer prometheus.Gatherer) error {
+	s.controllersMu.Lock()
+	s.controllers = append(s.controllers, controllerMux{name: name, mux: mux, gatherer: gatherer})
+	s.controllersMu.Unlock()
+
+	<-s.done
+	return s.err
+}
+
+// Run listens on the configured address and admin socket until ctx is done.
+func (s *Server) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var listeners []func(context.Context) error
+	if s.config.Address != "" {
+		listeners = append(listeners, s.serveTCP)
+	}
+	if s.config.AdminSocket != "" {
+		listeners = append(listeners, s.serveAdminSocket)
+	}
+	errCh := make(chan error, len(listeners))
+	for _, listen := range listeners {
+		go func() {
+			errCh <- listen(ctx)
+		}()
+	}
+	var err error
+	for range listeners {
+		if listenErr := <-errCh; listenErr != nil && err == nil {
+			// Stop the other listener too.
+			err = listenErr
+			cancel()
+		}
+	}
+	return s.stop(err)
+}
+
+// serveTCP serves the configured address until ctx is done.
+func (s *Server) serveTCP(ctx context.Context) error {
+	logger := klog.FromContext(ctx)
+	srv := &http.Server{
+		Addr:    s.config.Address,
+		Handler: http.HandlerFunc(s.serveHTTP),
+	}
+
+	if s.config.TLSCertFile != "" {
+		servingCert, err := dynamiccertificates.NewDynamicServingContentFromFiles("serving-cert", s.config.TLSCertFile, s.config.TLSPrivateKeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load the serving certificate: %w", err)
+		}
+		go servingCert.Run(ctx, 1)
+
+		srv.TLSConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+				cert, key := servingCert.CurrentCertKeyContent()
+				keyPair, err := tls.X509KeyPair(cert, key)
+				if err != nil {
+					return nil, err
+				}
+				return &keyPair, nil
+			},
+		}
+		if s.clientCA != nil {
+			// The client certificate is verified by the authenticator.
+			go s.clientCA.Run(ctx, 1)
+			srv.TLSConfig.ClientAuth = tls.RequestClientCert
+		}
+	}
+
+	go shutdownOnDone(ctx, srv)
+
+	logger.Info("Diagnostics server listening", "address", s.config.Address, "tls", srv.TLSConfig != nil, "delegatedAuth", s.config.DelegatedAuth)
+	var err error
+	if srv.TLSConfig != nil {
+		err = srv.ListenAndServeTLS("", "")
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		err = nil
+	}
+	return err
+}
+
+// serveAdminSocket serves the admin socket until ctx is done.
+func (s *Server) serveAdminSocket(ctx context.Context) error {
+	path := s.config.AdminSocket
+	// Remove the socket left behind by a previous instance.
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove the stale admin socket: %w", err)
+	}
+	listener, err := listenPrivate(path)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+
+	srv := &http.Server{Handler: http.HandlerFunc(s.serveLocal)}
+	go shutdownOnDone(ctx, srv)
+
+	klog.FromContext(ctx).Info("Admin socket listening", "path", path)
+	err = srv.Serve(listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		err = nil
+	}
+	return err
+}
+
+// listenPrivate listens on a unix socket at path that only the user of the
+// process can connect to. The socket is created and restricted in a private
+// directory and only then moved to path, so that it's never accessible to
+// other users, whatever the umask of the process.
+func listenPrivate(path string) (*net.UnixListener, error) {
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".admin-socket-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the admin socket: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	tmpPath := filepath.Join(dir, "socket")
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: tmpPath, Net: "unix"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on the admin socket: %w", err)
+	}
+	// The socket is removed from path by serveAdminSocket.
+	listener.SetUnlinkOnClose(false)
+	if err := os.Chmod(tmpPath, 0o600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict the admin socket: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to move the admin socket: %w", err)
+	}
+	return listener, nil
+}
+
+func shutdownOnDone(ctx context.Context, srv *http.Server) {
+	<-ctx.Done()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		klog.FromContext(ctx).Error(err, "Failed to shut down the diagnostics server")
+	}
+}
+
+func (s *Server) stop(err error) error {
+	s.err = err
+	close(s.done)
+	return err
+}
+
+// serveHTTP serves the health checks anonymously and everything else
+// through the authentication and authorization filters, if enabled.
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.isHealthzPath(r.URL.Path) {
+		s.route(w, r)
+		return
+	}
+	s.handler.ServeHTTP(w, r)
+}
+
+// serveLocal serves the admin socket, the handlers registered with
+// HandleLocal come first.
+func (s *Server) serveLocal(w http.ResponseWriter, r *http.Request) {
+	if h, pattern := s.localMux.Handler(r); pattern != "" {
+		h.ServeHTTP(w, r)
+		return
+	}
+	s.route(w, r)
+}
+
+func (s *Server) route(w http.ResponseWriter, r *http.Request) {
+	if h, pattern := s.mux.Handler(r); pattern != "" {
+		h.ServeHTTP(w, r)
+		return
+	}
+
+	controllers := s.controllerMuxes()
+	if c, _, ok := splitControllerPrefix(controllers, r.URL.Path); ok {
+		http.StripPrefix("/"+c.name, c.mux).ServeHTTP(w, r)
+		return
+	}
+	for _, c := range controllers {
+		if h, pattern := c.mux.Handler(r); pattern != "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
+
+// controllerMuxes returns a snapshot of the mounted sidecars.
+func (s *Server) controllerMuxes() []controllerMux {
+	s.controllersMu.RLock()
+	defer s.controllersMu.RUnlock()
+	return append([]controllerMux(nil), s.controllers...)
+}
+
+// splitControllerPrefix splits /<controller>/<rest> when <controller> is a
+// mounted sidecar.
+func splitControllerPrefix(controllers []controllerMux, path string) (controllerMux, string, bool) {
+	name, rest, found := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if !found {
+		return controllerMux{}, "", false
+	}
+	for _, c := range controllers {
+		if c.name == name {
+			return c, "/" + rest, true
+		}
+	}
+	return controllerMux{}, "", false
+}
+
+func (s *Server) isHealthzPath(path string) bool {
+	if _, rest, ok := splitControllerPrefix(s.controllerMuxes(), path); ok {
+		path = rest
+	}
+	return path == "/healthz" || strings.HasPrefix(path, "/healthz/")
+}
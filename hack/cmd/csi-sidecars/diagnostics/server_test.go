@@ -0,0 +1,154 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnostics
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	certutil "k8s.io/client-go/util/cert"
+)
+
+// writeServingCert writes a self-signed certificate for host.
+func writeServingCert(t *testing.T, certFile, keyFile, host string) {
+	t.Helper()
+	cert, key, err := certutil.GenerateSelfSignedCertKey(host, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to generate a certificate: %v", err)
+	}
+	if err := os.WriteFile(certFile, cert, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, key, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// freeAddress returns a local address nothing listens on.
+func freeAddress(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+// servedHost returns the host of the certificate served on addr.
+func servedHost(addr string) (string, error) {
+	conn, err := tls.Dial("tcp", addr, &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates[0].Subject.CommonName, nil
+}
+
+func TestTLSReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	writeServingCert(t, certFile, keyFile, "first.example.com")
+
+	addr := freeAddress(t)
+	s, err := NewServer(Config{
+		Address:           addr,
+		TLSCertFile:       certFile,
+		TLSPrivateKeyFile: keyFile,
+	})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- s.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Run failed: %v", err)
+		}
+	}()
+
+	waitForHost := func(expected string) {
+		t.Helper()
+		var host string
+		err := wait.PollUntilContextTimeout(ctx, 100*time.Millisecond, 30*time.Second, true, func(context.Context) (bool, error) {
+			host, _ = servedHost(addr)
+			return host == expected, nil
+		})
+		if err != nil {
+			t.Fatalf("expected the certificate of %s to be served, got %q", expected, host)
+		}
+	}
+
+	waitForHost("first.example.com")
+	writeServingCert(t, certFile, keyFile, "second.example.com")
+	waitForHost("second.example.com")
+}
+
+func TestAdminSocketPermissions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "admin.sock")
+	// A stale socket of a previous instance.
+	if err := os.WriteFile(path, nil, 0o666); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewServer(Config{AdminSocket: path})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- s.Run(ctx)
+	}()
+
+	var info os.FileInfo
+	err = wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 30*time.Second, true, func(context.Context) (bool, error) {
+		info, err = os.Stat(path)
+		return err == nil && info.Mode()&os.ModeSocket != 0, nil
+	})
+	if err != nil {
+		t.Fatalf("the admin socket wasn't created: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("expected the admin socket to have permissions 0600, got %#o", perm)
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected only the admin socket in its directory, got %v", entries)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Run failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the admin socket to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"github.com/kubernetes-csi/csi-sidecars/pkg/operations"
+)
+
+// sidecarInformerKinds are the kinds of objects every sidecar watches with
+// the informer factory it creates. The AIO binary reads them from the same
+// informers instead of watching them a second time, and only with the
+// permissions of a sidecar that needs them anyway.
+var sidecarInformerKinds = map[string][]string{
+	"attacher":    {"VolumeAttachment", "PersistentVolume"},
+	"provisioner": {"PersistentVolumeClaim", "VolumeAttachment"},
+	"resizer":     {"PersistentVolume", "PersistentVolumeClaim"},
+}
+
+// Indexes added to the shared informers.
+const (
+	// volumeHandleIndex indexes the CSI PersistentVolumes by volume handle.
+	volumeHandleIndex = "volumeHandle"
+	// persistentVolumeIndex indexes the VolumeAttachments by PV name.
+	persistentVolumeIndex = "persistentVolume"
+)
+
+// sharedInformers are the informers of the sidecars the AIO binary reads
+// from, the first sidecar watching a kind provides it. They are nil while no
+// running sidecar watches the kind. The informers only run while their
+// sidecar holds its leader election lease.
+var sharedInformers struct {
+	sync.RWMutex
+	vas  cache.SharedIndexInformer
+	pvs  cache.SharedIndexInformer
+	pvcs cache.SharedIndexInformer
+}
+
+// useSidecarInformers records the informer factory of a sidecar, do_sync.sh
+// inserts the call right after the sidecar creates it so that the informers
+// are created before the sidecar starts the factory.
+func useSidecarInformers(sidecar string, factory informers.SharedInformerFactory) {
+	sharedInformers.Lock()
+	defer sharedInformers.Unlock()
+	for _, kind := range sidecarInformerKinds[sidecar] {
+		switch kind {
+		case "VolumeAttachment":
+			if sharedInformers.vas == nil {
+				sharedInformers.vas = factory.Storage().V1().VolumeAttachments().Informer()
+				addIndexer(sharedInformers.vas, persistentVolumeIndex, func(obj any) ([]string, error) {
+					va, ok := obj.(*storagev1.VolumeAttachment)
+					if !ok || va.Spec.Source.PersistentVolumeName == nil {
+						return nil, nil
+					}
+					return []string{*va.Spec.Source.PersistentVolumeName}, nil
+				})
+				klog.V(4).InfoS("Using the informer of a sidecar", "sidecar", sidecar, "kind", kind)
+			}
+		case "PersistentVolume":
+			if sharedInformers.pvs == nil {
+				sharedInformers.pvs = factory.Core().V1().PersistentVolumes().Informer()
+				addIndexer(sharedInformers.pvs, volumeHandleIndex, func(obj any) ([]string, error) {
+					pv, ok := obj.(*v1.PersistentVolume)
+					if !ok || pv.Spec.CSI == nil {
+						return nil, nil
+					}
+					return []string{pv.Spec.CSI.VolumeHandle}, nil
+				})
+				klog.V(4).InfoS("Using the informer of a sidecar", "sidecar", sidecar, "kind", kind)
+			}
+		case "PersistentVolumeClaim":
+			if sharedInformers.pvcs == nil {
+				sharedInformers.pvcs = factory.Core().V1().PersistentVolumeClaims().Informer()
+				klog.V(4).InfoS("Using the informer of a sidecar", "sidecar", sidecar, "kind", kind)
+			}
+		}
+	}
+}
+
+// addIndexer adds an index to an informer, it's only logged when that fails
+// since the index only serves diagnostics.
+func addIndexer(informer cache.SharedIndexInformer, name string, index cache.IndexFunc) {
+	if err := informer.AddIndexers(cache.Indexers{name: index}); err != nil {
+		klog.ErrorS(err, "Failed to add an index to a shared informer", "index", name)
+	}
+}
+
+// sharedInformer returns the informer of a kind, or nil.
+func sharedInformer(kind string) cache.SharedIndexInformer {
+	sharedInformers.RLock()
+	defer sharedInformers.RUnlock()
+	switch kind {
+	case "VolumeAttachment":
+		return sharedInformers.vas
+	case "PersistentVolume":
+		return sharedInformers.pvs
+	case "PersistentVolumeClaim":
+		return sharedInformers.pvcs
+	}
+	return nil
+}
+
+// getShared returns an object of kind from the shared informers.
+func getShared[T any](kind, namespace, name string) (T, bool) {
+	var zero T
+	informer := sharedInformer(kind)
+	if informer == nil {
+		return zero, false
+	}
+	key := name
+	if namespace != "" {
+		key = namespace + "/" + name
+	}
+	obj, exists, err := informer.GetStore().GetByKey(key)
+	if err != nil || !exists {
+		return zero, false
+	}
+	typed, ok := obj.(T)
+	return typed, ok
+}
+
+// listSharedKeys returns the queue keys of every object of kind in the
+// shared informers.
+func listSharedKeys(kind string) ([]string, error) {
+	informer := sharedInformer(kind)
+	if informer == nil {
+		return nil, fmt.Errorf("no running controller watches %s objects", kind)
+	}
+	if !informer.HasSynced() {
+		return nil, fmt.Errorf("the %s objects aren't synced yet, the controllers watching them run on the leader only", kind)
+	}
+	return informer.GetStore().ListKeys(), nil
+}
+
+// volumeAttachmentNode returns the node a VolumeAttachment attaches to.
+func volumeAttachmentNode(_, name string) string {
+	va, ok := getShared[*storagev1.VolumeAttachment]("VolumeAttachment", "", name)
+	if !ok {
+		return ""
+	}
+	return va.Spec.NodeName
+}
+
+// claimSelectedNode returns the node the scheduler selected for a PVC.
+func claimSelectedNode(namespace, name string) string {
+	pvc, ok := getShared[*v1.PersistentVolumeClaim]("PersistentVolumeClaim", namespace, name)
+	if !ok {
+		return ""
+	}
+	return pvc.Annotations[selectedNodeAnnotation]
+}
+
+// resolveSharedVolume implements operations.VolumeResolver with the shared
+// informers.
+func resolveSharedVolume(handle string) (string, []operations.Object) {
+	pvs := sharedInformer("PersistentVolume")
+	if pvs == nil {
+		return "", nil
+	}
+	objs, err := pvs.GetIndexer().ByIndex(volumeHandleIndex, handle)
+	if err != nil || len(objs) == 0 {
+		return "", nil
+	}
+	pv := objs[0].(*v1.PersistentVolume)
+	objects := []operations.Object{{Kind: "PersistentVolume", Name: pv.Name}}
+	if ref := pv.Spec.ClaimRef; ref != nil {
+		objects = append(objects, operations.Object{Kind: "PersistentVolumeClaim", Namespace: ref.Namespace, Name: ref.Name})
+	}
+	if vas := sharedInformer("VolumeAttachment"); vas != nil {
+		vaObjs, _ := vas.GetIndexer().ByIndex(persistentVolumeIndex, pv.Name)
+		for _, obj := range vaObjs {
+			objects = append(objects, operations.Object{Kind: "VolumeAttachment", Name: obj.(*storagev1.VolumeAttachment).Name})
+		}
+	}
+	return pv.Name, objects
+}
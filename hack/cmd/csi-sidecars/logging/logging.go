@@ -0,0 +1,110 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logging
+
+import (
+	"sync"
+
+	"github.com/go-logr/logr"
+	"k8s.io/klog/v2"
+)
+
+var (
+	verbosityMu sync.RWMutex
+	verbosity   = map[string]int{}
+)
+
+// SetVerbosity sets the log level of controller instead of -v, e.g.
+// --attacher-v=5, a negative level uses -v. It applies to the loggers
+// ControllerLogger returned before too.
+func SetVerbosity(controller string, v int) {
+	verbosityMu.Lock()
+	defer verbosityMu.Unlock()
+	verbosity[controller] = v
+}
+
+func verbosityOf(controller string) int {
+	verbosityMu.RLock()
+	defer verbosityMu.RUnlock()
+	if v, ok := verbosity[controller]; ok {
+		return v
+	}
+	return -1
+}
+
+// ControllerLogger returns the logger a controller runs with. It's named
+// after the controller, every message has a controller key, and it logs with
+// the level set with SetVerbosity. Only the logs of the loggers from the
+// context of the controller follow it, the klog.V calls keep using -v.
+func ControllerLogger(logger klog.Logger, controller string) klog.Logger {
+	logger = logger.WithName(controller).WithValues("controller", controller)
+	sink := logger.GetSink()
+	if withCallDepth, ok := sink.(logr.CallDepthLogSink); ok {
+		// Skip the frame of verbositySink.Info or verbositySink.Error.
+		sink = withCallDepth.WithCallDepth(1)
+	}
+	return logr.New(&verbositySink{LogSink: sink, controller: controller})
+}
+
+// verbositySink logs the messages up to the level of its controller. The
+// sink it wraps drops the messages above -v, so they're passed to it as
+// level 0.
+type verbositySink struct {
+	logr.LogSink
+	controller string
+}
+
+var _ logr.CallDepthLogSink = &verbositySink{}
+
+// Init does nothing, the wrapped sink was initialized by its logger.
+func (s *verbositySink) Init(logr.RuntimeInfo) {}
+
+func (s *verbositySink) Enabled(level int) bool {
+	if v := verbosityOf(s.controller); v >= 0 {
+		return level <= v
+	}
+	return s.LogSink.Enabled(level)
+}
+
+func (s *verbositySink) Info(level int, msg string, keysAndValues ...any) {
+	if verbosityOf(s.controller) >= 0 {
+		level = 0
+	}
+	s.LogSink.Info(level, msg, keysAndValues...)
+}
+
+// Error is logged whatever the level, like the errors of the wrapped sink.
+// It's defined rather than promoted from the wrapped sink so that the frame
+// skipped by ControllerLogger is the same as for Info.
+func (s *verbositySink) Error(err error, msg string, keysAndValues ...any) {
+	s.LogSink.Error(err, msg, keysAndValues...)
+}
+
+func (s *verbositySink) WithValues(keysAndValues ...any) logr.LogSink {
+	return &verbositySink{LogSink: s.LogSink.WithValues(keysAndValues...), controller: s.controller}
+}
+
+func (s *verbositySink) WithName(name string) logr.LogSink {
+	return &verbositySink{LogSink: s.LogSink.WithName(name), controller: s.controller}
+}
+
+func (s *verbositySink) WithCallDepth(depth int) logr.LogSink {
+	if sink, ok := s.LogSink.(logr.CallDepthLogSink); ok {
+		return &verbositySink{LogSink: sink.WithCallDepth(depth), controller: s.controller}
+	}
+	return s
+}
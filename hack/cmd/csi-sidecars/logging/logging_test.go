@@ -0,0 +1,71 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"k8s.io/klog/v2"
+	"k8s.io/klog/v2/textlogger"
+)
+
+// line returns the line it's called from.
+func line() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line
+}
+
+// TestControllerLoggerCaller checks that the messages of a controller logger
+// are logged with the location of their caller.
+func TestControllerLoggerCaller(t *testing.T) {
+	tests := []struct {
+		name string
+		// log logs a message and returns the line it's logged from.
+		log func(logger klog.Logger) int
+	}{
+		{
+			name: "info",
+			log:  func(logger klog.Logger) int { logger.Info("message"); return line() },
+		},
+		{
+			name: "verbose info",
+			log:  func(logger klog.Logger) int { logger.V(4).Info("message"); return line() },
+		},
+		{
+			name: "error",
+			log:  func(logger klog.Logger) int { logger.Error(errors.New("failure"), "message"); return line() },
+		},
+	}
+
+	SetVerbosity("attacher", 5)
+	defer SetVerbosity("attacher", -1)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := ControllerLogger(textlogger.NewLogger(textlogger.NewConfig(textlogger.Output(&buf))), "attacher")
+			location := fmt.Sprintf("logging_test.go:%d]", test.log(logger))
+			if !strings.Contains(buf.String(), location) {
+				t.Errorf("expected the message to be logged from %s, got %q", location, buf.String())
+			}
+		})
+	}
+}
@@ -20,6 +20,9 @@ import (
 	"context"
 	goflag "flag"
 	"fmt"
+	"maps"
+	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -28,16 +31,33 @@ import (
 	logsapi "k8s.io/component-base/logs/api/v1"
 	"github.com/kubernetes-csi/csi-lib-utils/standardflags"
 	"github.com/kubernetes-csi/csi-sidecars/cmd/csi-sidecars/config"
+	"github.com/kubernetes-csi/csi-sidecars/cmd/csi-sidecars/diagnostics"
+	"github.com/kubernetes-csi/csi-sidecars/cmd/csi-sidecars/logging"
+	aiometrics "github.com/kubernetes-csi/csi-sidecars/cmd/csi-sidecars/metrics"
+	"github.com/kubernetes-csi/csi-sidecars/cmd/csi-sidecars/rbac"
 	attacherconfig "github.com/kubernetes-csi/csi-sidecars/pkg/attacher/cmd/csi-attacher/config"
+	"github.com/kubernetes-csi/csi-sidecars/pkg/featuregates"
+	"github.com/kubernetes-csi/csi-sidecars/pkg/operations"
+	provisionerconfig "github.com/kubernetes-csi/csi-sidecars/pkg/provisioner/cmd/csi-provisioner/config"
+	"github.com/kubernetes-csi/csi-sidecars/pkg/queues"
+	resizerconfig "github.com/kubernetes-csi/csi-sidecars/pkg/resizer/cmd/csi-resizer/config"
+	"github.com/kubernetes-csi/csi-sidecars/pkg/tracing"
 	flag "github.com/spf13/pflag"
 	"sigs.k8s.io/sig-storage-lib-external-provisioner/v13/controller"
 
 	utilfeature "k8s.io/apiserver/pkg/util/feature"
-	utilflag "k8s.io/component-base/cli/flag"
 	"k8s.io/component-base/logs"
+	_ "k8s.io/component-base/logs/json/register"
+	"k8s.io/component-base/metrics/legacyregistry"
 	"k8s.io/klog/v2"
 )
 
+// tracingShutdownTimeout bounds how long pending spans are flushed on exit.
+const tracingShutdownTimeout = 5 * time.Second
+
+// rbacCheckTimeout bounds how long the permissions are checked at startup.
+const rbacCheckTimeout = 30 * time.Second
+
 var (
 	master                      *string
 	kubeconfig                  *string
@@ -67,39 +87,43 @@ var (
 	maxGRPCLogLength            *int
 	maxEntries                  *int
 	reconcileSync               *time.Duration
-)
-
-var (
 
 	// Provisioner specific
-	kubeAPICapacityQPS             = flag.Float32("provisioner-kube-api-capacity-qps", 1, "QPS to use for storage capacity updates while communicating with the kubernetes apiserver. Defaults to 1.0.")
-	kubeAPICapacityBurst           = flag.Int("provisioner-kube-api-capacity-burst", 5, "Burst to use for storage capacity updates while communicating with the kubernetes apiserver. Defaults to 5.")
-	volumeNamePrefix               = flag.String("provisioner-volume-name-prefix", "pvc", "Prefix to apply to the name of a created volume.")
-	volumeNameUUIDLength           = flag.Int("provisioner-volume-name-uuid-length", -1, "Truncates generated UUID of a created volume to this length. Defaults behavior is to NOT truncate.")
-	finalizerThreads               = flag.Uint("provisioner-cloning-protection-threads", 1, "Number of simultaneously running threads, handling cloning finalizer removal")
-	capacityThreads                = flag.Uint("provisioner-capacity-threads", 1, "Number of simultaneously running threads, handling CSIStorageCapacity objects")
-	strictTopology                 = flag.Bool("provisioner-strict-topology", false, "Late binding: pass only selected node topology to CreateVolume Request, unlike default behavior of passing aggregated cluster topologies that match with topology keys of the selected node.")
-	immediateTopology              = flag.Bool("provisioner-immediate-topology", true, "Immediate binding: pass aggregated cluster topologies for all nodes where the CSI driver is available (enabled, the default) or no topology requirements (if disabled).")
-	extraCreateMetadata            = flag.Bool("provisioner-extra-create-metadata", false, "If set, add pv/pvc metadata to plugin create requests as parameters.")
-	enableProfile                  = flag.Bool("provisioner-enable-pprof", false, "Enable pprof profiling on the TCP network address specified by --http-endpoint. The HTTP path is `/debug/pprof/`.")
-	enableCapacity                 = flag.Bool("provisioner-enable-capacity", false, "This enables producing CSIStorageCapacity objects with capacity information from the driver's GetCapacity call.")
-	capacityImmediateBinding       = flag.Bool("provisioner-capacity-for-immediate-binding", false, "Enables producing capacity information for storage classes with immediate binding. Not needed for the Kubernetes scheduler, maybe useful for other consumers or for debugging.")
-	capacityPollInterval           = flag.Duration("provisioner-capacity-poll-interval", time.Minute, "How long the external-provisioner waits before checking for storage capacity changes.")
-	capacityOwnerrefLevel          = flag.Int("provisioner-capacity-ownerref-level", 1, "The level indicates the number of objects that need to be traversed starting from the pod identified by the POD_NAME and NAMESPACE environment variables to reach the owning object for CSIStorageCapacity objects: -1 for no owner, 0 for the pod itself, 1 for a StatefulSet or DaemonSet, 2 for a Deployment, etc.")
-	enableNodeDeployment           = flag.Bool("provisioner-node-deployment", false, "Enables deploying the external-provisioner together with a CSI driver on nodes to manage node-local volumes.")
-	nodeDeploymentImmediateBinding = flag.Bool("provisioner-node-deployment-immediate-binding", true, "Determines whether immediate binding is supported when deployed on each node.")
-	nodeDeploymentBaseDelay        = flag.Duration("provisioner-node-deployment-base-delay", 20*time.Second, "Determines how long the external-provisioner sleeps initially before trying to own a PVC with immediate binding.")
-	nodeDeploymentMaxDelay         = flag.Duration("provisioner-node-deployment-max-delay", 60*time.Second, "Determines how long the external-provisioner sleeps at most before trying to own a PVC with immediate binding.")
-	controllerPublishReadOnly      = flag.Bool("provisioner-controller-publish-readonly", false, "This option enables PV to be marked as readonly at controller publish volume call if PVC accessmode has been set to ROX.")
-	preventVolumeModeConversion    = flag.Bool("provisioner-prevent-volume-mode-conversion", true, "Prevents an unauthorised user from modifying the volume mode when creating a PVC from an existing VolumeSnapshot.")
-	provisionController            *controller.ProvisionController
+	kubeAPICapacityQPS             *float32
+	kubeAPICapacityBurst           *int
+	volumeNamePrefix               *string
+	volumeNameUUIDLength           *int
+	finalizerThreads               *uint
+	capacityThreads                *uint
+	strictTopology                 *bool
+	immediateTopology              *bool
+	extraCreateMetadata            *bool
+	enableProfile                  *bool
+	enableCapacity                 *bool
+	capacityImmediateBinding       *bool
+	capacityPollInterval           *time.Duration
+	capacityOwnerrefLevel          *int
+	enableNodeDeployment           *bool
+	nodeDeploymentImmediateBinding *bool
+	nodeDeploymentBaseDelay        *time.Duration
+	nodeDeploymentMaxDelay         *time.Duration
+	controllerPublishReadOnly      *bool
+	preventVolumeModeConversion    *bool
 
 	// Resizer specific
-	handleVolumeInUseError = flag.Bool("resizer-handle-volume-inuse-error", true, "Flag to turn on/off capability to handle volume in use error in resizer controller. Defaults to true if not set.")
-	extraModifyMetadata    = flag.Bool("resizer-extra-modify-metadata", false, "If set, add pv/pvc metadata to plugin modify requests as parameters.")
+	handleVolumeInUseError *bool
+	extraModifyMetadata    *bool
+)
 
-	featureGates map[string]bool
+var (
+	provisionController *controller.ProvisionController
+
+	featureGates featuregates.Gates
 	version      = "unknown"
+
+	// diagnosticsServer serves --http-endpoint for every sidecar, do_sync.sh
+	// rewrites their http.ListenAndServe calls to mount on it instead.
+	diagnosticsServer *diagnostics.Server
 )
 
 // copyFlagsFromConfigToGlobalVars copies flags from standardflags.Configuration
@@ -144,45 +168,252 @@ func copyFlagsFromConfigToGlobalVars() {
 	// TODO: define if timeout should be global or not
 	timeout = &config.Configuration.AttacherConfiguration.Timeout
 	operationTimeout = &config.Configuration.AttacherConfiguration.Timeout
+
+	// Provisioner-specific flags from config.Configuration.ProvisionerConfiguration
+	provisioner := &config.Configuration.ProvisionerConfiguration
+	kubeAPICapacityQPSFloat32 := float32(provisioner.KubeAPICapacityQPS)
+	kubeAPICapacityQPS = &kubeAPICapacityQPSFloat32
+	kubeAPICapacityBurst = &provisioner.KubeAPICapacityBurst
+	volumeNamePrefix = &provisioner.VolumeNamePrefix
+	volumeNameUUIDLength = &provisioner.VolumeNameUUIDLength
+	finalizerThreads = &provisioner.FinalizerThreads
+	capacityThreads = &provisioner.CapacityThreads
+	strictTopology = &provisioner.StrictTopology
+	immediateTopology = &provisioner.ImmediateTopology
+	extraCreateMetadata = &provisioner.ExtraCreateMetadata
+	enableProfile = &provisioner.EnableProfile
+	enableCapacity = &provisioner.EnableCapacity
+	capacityImmediateBinding = &provisioner.CapacityImmediateBinding
+	capacityPollInterval = &provisioner.CapacityPollInterval
+	capacityOwnerrefLevel = &provisioner.CapacityOwnerrefLevel
+	enableNodeDeployment = &provisioner.EnableNodeDeployment
+	nodeDeploymentImmediateBinding = &provisioner.NodeDeploymentImmediateBinding
+	nodeDeploymentBaseDelay = &provisioner.NodeDeploymentBaseDelay
+	nodeDeploymentMaxDelay = &provisioner.NodeDeploymentMaxDelay
+	controllerPublishReadOnly = &provisioner.ControllerPublishReadOnly
+	preventVolumeModeConversion = &provisioner.PreventVolumeModeConversion
+
+	// Resizer-specific flags from config.Configuration.ResizerConfiguration
+	handleVolumeInUseError = &config.Configuration.ResizerConfiguration.HandleVolumeInUseError
+	extraModifyMetadata = &config.Configuration.ResizerConfiguration.ExtraModifyMetadata
+}
+
+// registerFlags registers every flag of the AIO binary, or of the standalone
+// sidecar it runs as. It returns the logging configuration set by the flags.
+func registerFlags(goflags *goflag.FlagSet, flags *flag.FlagSet, standalone string) *logsapi.LoggingConfiguration {
+	flags.Var(&featureGates, "feature-gates", featuregates.Usage())
+
+	klog.InitFlags(goflags)
+	standardflags.RegisterCommonFlags(goflags)
+	config.RegisterAIOFlags(goflags)
+	registerSidecarFlags(goflags, standalone)
+	config.RegisterAIOMetricsFlags(flags)
+	c := logsapi.NewLoggingConfiguration()
+	logsapi.AddFlags(c, flags)
+	flags.AddGoFlagSet(goflags)
+	return c
+}
+
+// registerSidecarFlags registers the flags of every sidecar prefixed with
+// its name, e.g. --attacher-worker-threads, except for the standalone
+// sidecar whose flags are registered unprefixed. The flags of the
+// standalone sidecar that the AIO binary has under another name, e.g.
+// --workers of the resizer, are registered as aliases of the AIO flags.
+func registerSidecarFlags(goflags *goflag.FlagSet, standalone string) {
+	sidecarFlags := goflag.NewFlagSet("csi-"+standalone, goflag.ExitOnError)
+	if standalone == "attacher" {
+		attacherconfig.RegisterAttacherFlags(sidecarFlags, &config.Configuration.AttacherConfiguration)
+	} else {
+		attacherconfig.RegisterAttacherFlagsWithPrefix(goflags, &config.Configuration.AttacherConfiguration)
+	}
+	if standalone == "provisioner" {
+		provisionerconfig.RegisterProvisionerFlags(sidecarFlags, &config.Configuration.ProvisionerConfiguration)
+	} else {
+		provisionerconfig.RegisterProvisionerFlagsWithPrefix(goflags, &config.Configuration.ProvisionerConfiguration)
+	}
+	if standalone == "resizer" {
+		resizerconfig.RegisterResizerFlags(sidecarFlags, &config.Configuration.ResizerConfiguration)
+	} else {
+		resizerconfig.RegisterResizerFlagsWithPrefix(goflags, &config.Configuration.ResizerConfiguration)
+	}
+	for alias, name := range config.StandaloneAliases[standalone] {
+		if f := goflags.Lookup(name); f != nil {
+			sidecarFlags.Var(f.Value, alias, f.Usage)
+		}
+	}
+	sidecarFlags.VisitAll(func(f *goflag.Flag) {
+		standaloneFlags[f.Name] = true
+	})
+	addMissingFlags(goflags, sidecarFlags)
 }
 
 func main() {
-	flag.Var(utilflag.NewMapStringBool(&featureGates), "feature-gates", "A set of key=value pairs that describe feature gates for alpha/experimental features. "+
-		"Options are:\n"+strings.Join(utilfeature.DefaultFeatureGate.KnownFeatures(), "\n"))
+	runSubcommand()
+	standalone := standaloneController()
 
-	klog.InitFlags(nil)
-	standardflags.RegisterCommonFlags(goflag.CommandLine)
-	config.RegisterAIOFlags(goflag.CommandLine)
-	attacherconfig.RegisterAttacherFlagsWithPrefix(goflag.CommandLine, &config.Configuration.AttacherConfiguration)
+	// The logging feature gates, e.g. LoggingBetaOptions, aren't specific to a
+	// controller, they're set without a prefix in --feature-gates.
+	if err := logsapi.AddFeatureGates(utilfeature.DefaultMutableFeatureGate); err != nil {
+		klog.Fatal(err)
+	}
+	c := registerFlags(goflag.CommandLine, flag.CommandLine, standalone)
 	standardflags.AddAutomaxprocs(klog.Infof)
-	c := logsapi.NewLoggingConfiguration()
-	logsapi.AddFlags(c, flag.CommandLine)
-	flag.CommandLine.AddGoFlagSet(goflag.CommandLine)
 	flag.Set("logtostderr", "true")
 
-	// Resizer specific
-	// fg := featuregate.NewFeatureGate()
-	// logsapi.AddFeatureGates(fg)
-	// c := logsapi.NewLoggingConfiguration()
-	// logsapi.AddGoFlags(c, goflag.CommandLine)
 	logs.InitLogs()
+	defer logs.FlushLogs()
+
+	// Accept the unprefixed flags of the standalone sidecars, e.g.
+	// --worker-threads for --attacher-worker-threads, they're resolved
+	// once --controllers is known. Like the standalone sidecars, accept
+	// long flags with a single dash too, e.g. -csi-address.
+	args, legacyFlags, err := config.ExtractLegacyFlags(flag.CommandLine, config.NormalizeArgs(os.Args[1:]))
+	if err != nil {
+		klog.Fatal(err)
+	}
+	if err := flag.CommandLine.Parse(args); err != nil {
+		klog.Fatal(err)
+	}
+	// The CSI_SIDECARS_<FLAG> environment variables set the flags that aren't
+	// set on the command line, the --config file the remaining ones.
+	commandLine := config.ChangedFlags(flag.CommandLine)
+	for name := range commandLine {
+		config.SetSource(name, config.SourceFlag)
+	}
+	envFlags, envWarnings, err := config.ApplyEnv(flag.CommandLine, commandLine)
+	if err != nil {
+		klog.Fatal(err)
+	}
+	for _, warning := range envWarnings {
+		klog.Warning(warning)
+	}
+	overridden := maps.Clone(commandLine)
+	maps.Copy(overridden, envFlags)
+	var fileValues map[string]string
+	if config.Configuration.ConfigFile != "" {
+		if fileValues, err = config.ReadFile(config.Configuration.ConfigFile); err != nil {
+			klog.Fatal(err)
+		}
+		if err := config.ApplyFile(flag.CommandLine, fileValues, overridden); err != nil {
+			klog.Fatal(err)
+		}
+	}
+	if standalone != "" {
+		if config.Configuration.Controllers != "" && config.Configuration.Controllers != standalone {
+			klog.Warningf("Ignoring --controllers=%s, running as csi-%s", config.Configuration.Controllers, standalone)
+		}
+		config.Configuration.Controllers = standalone
+	}
+	// --controllers may come from the environment or the --config file too.
+	legacy, warnings, err := config.ResolveLegacyFlags(flag.CommandLine, legacyFlags, config.Configuration.Controllers, commandLine)
+	if err != nil {
+		klog.Fatal(err)
+	}
+	for _, warning := range warnings {
+		klog.Warning(warning)
+	}
+	maps.Copy(overridden, legacy)
 
-	flag.Parse()
+	// The feature gates are applied first, Validate checks the flags that
+	// depend on them.
+	if err := featuregates.Apply(featureGates); err != nil {
+		klog.Fatal(err)
+	}
+
+	// Report every invalid flag at once.
+	setFlags := maps.Clone(overridden)
+	for name := range fileValues {
+		setFlags[name] = true
+	}
+	if err := config.Configuration.Validate(setFlags); err != nil {
+		klog.Errorf("Invalid configuration:\n%v", err)
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
 
 	copyFlagsFromConfigToGlobalVars()
 
-	// Resizier specific
-	/*if err := logsapi.ValidateAndApply(c, fg); err != nil {
+	if err := loadControllerKubeConfigs(strings.Split(config.Configuration.Controllers, ",")); err != nil {
+		klog.Fatal(err)
+	}
+
+	// The logging configuration applies to every controller, do_sync.sh
+	// removes the one of each sidecar.
+	if err := logsapi.ValidateAndApply(c, utilfeature.DefaultFeatureGate); err != nil {
 		klog.ErrorS(err, "LoggingConfiguration is invalid")
 		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
-	}*/
+	}
+	logConfigz(flag.CommandLine, standalone)
 
-	if err := utilfeature.DefaultMutableFeatureGate.SetFromMap(featureGates); err != nil {
+	// Metric cardinality settings must be in place before any sidecar
+	// creates its CSIMetricsManager.
+	if err := aiometrics.Apply(config.Configuration.Metrics); err != nil {
 		klog.Fatal(err)
 	}
 
+	// The CSI RPCs made without the context of an operation find it through
+	// the objects of their volume.
+	operations.SetVolumeResolver(resolveSharedVolume)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), &config.Configuration.Tracing, operations.Propagator{})
+	if err != nil {
+		klog.Fatal(err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			klog.ErrorS(err, "Failed to flush traces")
+		}
+	}()
+
+	// The rate limiters of the sidecars read the retry intervals and the
+	// Kubernetes API rate limit from here, so that a reload changes them.
+	// /debug/queues reports the backoff of the items with them too.
+	queues.SetBackoff("", config.Configuration.RetryIntervalStart, config.Configuration.RetryIntervalMax)
+	setKubeAPIRateLimits()
+	if slices.Contains(strings.Split(config.Configuration.Controllers, ","), "provisioner") {
+		queues.MarkUnregistered("provisioner", "its claim and volume queues are created inside sig-storage-lib-external-provisioner, "+
+			"only their depth and requeues and the items its rate limiter retries are listed")
+		// The library names the workqueues claims and volumes, their depth and
+		// requeues are read from the workqueue metrics.
+		queues.RegisterLibraryQueue("provisioner", "claimQueue", "claims")
+		queues.RegisterLibraryQueue("provisioner", "volumeQueue", "volumes")
+	}
+
+	if config.Configuration.RBACCheck != rbac.ModeNone {
+		if err := checkRBAC(strings.Split(config.Configuration.Controllers, ",")); err != nil {
+			if config.Configuration.RBACCheck == rbac.ModeFail {
+				klog.ErrorS(err, "Failed the RBAC check")
+				klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+			}
+			klog.Warning(err)
+		}
+	}
+
 	errs, ctx := errgroup.WithContext(context.Background())
 
+	if addr := diagnosticsAddress(); addr != "" || config.Configuration.AdminSocket != "" {
+		diagnosticsServer, err = newDiagnosticsServer(addr, standalone)
+		if err != nil {
+			klog.Fatal(err)
+		}
+		if config.Configuration.EnableAdminAPI || config.Configuration.AdminSocket != "" {
+			if err := setupAdminAPI(diagnosticsServer); err != nil {
+				klog.Fatal(err)
+			}
+		}
+		errs.Go(func() error {
+			return diagnosticsServer.Run(ctx)
+		})
+	}
+
+	if config.Configuration.ConfigFile != "" {
+		configReloader := newReloader(flag.CommandLine, config.Configuration.ConfigFile, overridden, fileValues, standalone)
+		errs.Go(func() error {
+			return configReloader.run(ctx)
+		})
+	}
+
 	controllersToEnable := map[string]bool{}
 	for _, ctrl := range strings.Split(*&config.Configuration.Controllers, ",") {
 		controllersToEnable[ctrl] = true
@@ -191,19 +422,19 @@ func main() {
 	// TODO: Get main from each sidecar to return an error so we can handle it here
 	if _, ok := controllersToEnable["attacher"]; ok {
 		errs.Go(func() error {
-			attacher_main(ctx)
+			attacher_main(controllerContext(ctx, "attacher"))
 			return fmt.Errorf("Attacher stopped")
 		})
 	}
 	if _, ok := controllersToEnable["provisioner"]; ok {
 		errs.Go(func() error {
-			provisioner_main(ctx)
+			provisioner_main(controllerContext(ctx, "provisioner"))
 			return fmt.Errorf("Provisioner stopped")
 		})
 	}
 	if _, ok := controllersToEnable["resizer"]; ok {
 		errs.Go(func() error {
-			resizer_main(ctx)
+			resizer_main(controllerContext(ctx, "resizer"))
 			return fmt.Errorf("Resizer stopped")
 		})
 	}
@@ -212,3 +443,103 @@ func main() {
 		panic(err)
 	}
 }
+
+// diagnosticsAddress returns the address the sidecars serve diagnostics on,
+// mirroring how each sidecar picks between --metrics-address and --http-endpoint.
+func diagnosticsAddress() string {
+	if standardflags.Configuration.MetricsAddress != "" {
+		return standardflags.Configuration.MetricsAddress
+	}
+	return standardflags.Configuration.HttpEndpoint
+}
+
+// checkRBAC checks that the controllers have the permissions they need with
+// their own identity, see --rbac-check.
+func checkRBAC(controllers []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), rbacCheckTimeout)
+	defer cancel()
+	opts := rbac.Options{}
+	if standardflags.Configuration.LeaderElection {
+		// Like the leader election of the sidecars, without a namespace the
+		// Leases are in the namespace of the pod.
+		opts.LeaderElectionNamespace = standardflags.Configuration.LeaderElectionNamespace
+		if opts.LeaderElectionNamespace == "" {
+			opts.LeaderElectionNamespace = inClusterNamespace()
+		}
+	}
+	if config.Configuration.ProvisionerConfiguration.EnableCapacity {
+		opts.CapacityNamespace = os.Getenv("NAMESPACE")
+		opts.CapacityOwnerrefLevel = config.Configuration.ProvisionerConfiguration.CapacityOwnerrefLevel
+	}
+
+	var results []rbac.Result
+	for _, controller := range controllers {
+		client, err := newControllerClientset(controller)
+		if err != nil {
+			return fmt.Errorf("failed to create a Clientset for the %s controller: %w", controller, err)
+		}
+		results = append(results, rbac.Check(ctx, client, controller, rbac.Rules(controller, opts))...)
+	}
+	return rbac.Report(results)
+}
+
+// inClusterNamespace returns the namespace of the pod, or "default" outside
+// of a cluster.
+func inClusterNamespace() string {
+	if data, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace"); err == nil {
+		if namespace := strings.TrimSpace(string(data)); namespace != "" {
+			return namespace
+		}
+	}
+	return "default"
+}
+
+// markRunning records that the controllers of a sidecar run until the
+// returned function is called, do_sync.sh inserts the call at the start of
+// the run function every sidecar starts its controllers with.
+func markRunning(controller string) func() {
+	if diagnosticsServer == nil {
+		return func() {}
+	}
+	return diagnosticsServer.MarkRunning(controller)
+}
+
+// controllerContext returns the context a controller runs with, its logger is
+// named after the controller and logs with the verbosity of --<controller>-v.
+// do_sync.sh makes the sidecars take their logger from it, the logs of the
+// global klog functions can't be told apart and keep using -v.
+func controllerContext(ctx context.Context, controller string) context.Context {
+	logging.SetVerbosity(controller, *config.Configuration.Verbosity[controller])
+	return klog.NewContext(ctx, logging.ControllerLogger(klog.FromContext(ctx), controller))
+}
+
+func newDiagnosticsServer(addr, standalone string) (*diagnostics.Server, error) {
+	serverConfig := diagnostics.Config{
+		Address:           addr,
+		TLSCertFile:       config.Configuration.TLSCertFile,
+		TLSPrivateKeyFile: config.Configuration.TLSPrivateKeyFile,
+		ClientCAFile:      config.Configuration.ClientCAFile,
+		DelegatedAuth:     config.Configuration.HTTPEndpointDelegatedAuth,
+		AdminSocket:       config.Configuration.AdminSocket,
+		Controllers:       strings.Split(config.Configuration.Controllers, ","),
+		MetricsPath:       standardflags.Configuration.MetricsPath,
+		// The registries of the sidecars only have their CSI metrics, the
+		// sidecars add the legacy registry when they serve them.
+		Gatherer: legacyregistry.DefaultGatherer,
+	}
+	if serverConfig.DelegatedAuth {
+		client, err := newClientset()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create a Clientset for delegated auth: %w", err)
+		}
+		serverConfig.Client = client
+	}
+	server, err := diagnostics.NewServer(serverConfig)
+	if err != nil {
+		return nil, err
+	}
+	server.Handle(operations.VolumePathPrefix, operations.VolumeHistoryHandler())
+	server.Handle(queues.DebugPath, queues.Handler())
+	server.Handle(config.ConfigzPath, configzHandler(flag.CommandLine, standalone))
+	return server, nil
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	goflag "flag"
+	"testing"
+	"time"
+
+	"github.com/kubernetes-csi/csi-sidecars/cmd/csi-sidecars/config"
+	flag "github.com/spf13/pflag"
+)
+
+// TestStandaloneFlags parses the flags documented in the README of every
+// standalone sidecar, the binary must accept them when it runs as that
+// sidecar.
+func TestStandaloneFlags(t *testing.T) {
+	common := []string{
+		"-v=5",
+		"--csi-address=/csi/csi.sock",
+		"--kubeconfig=/etc/kubeconfig",
+		"--leader-election",
+		"--leader-election-namespace=kube-system",
+		"--leader-election-lease-duration=15s",
+		"--leader-election-renew-deadline=10s",
+		"--leader-election-retry-period=5s",
+		"--http-endpoint=:8080",
+		"--metrics-path=/metrics",
+		"--kube-api-qps=5",
+		"--kube-api-burst=10",
+		"--retry-interval-start=1s",
+		"--retry-interval-max=5m",
+		"--feature-gates=ReleaseLeaderElectionOnExit=true",
+	}
+	tests := []struct {
+		sidecar string
+		args    []string
+		check   func(t *testing.T)
+	}{
+		{
+			sidecar: "attacher",
+			args: []string{
+				"--timeout=30s",
+				"--worker-threads=20",
+				"--max-entries=100",
+				"--reconcile-sync=2m",
+				"--resync=20m",
+				"--default-fstype=ext4",
+				"--max-grpc-log-length=1000",
+			},
+			check: func(t *testing.T) {
+				if c := config.Configuration.AttacherConfiguration; c.WorkerThreads != 20 || c.Timeout != 30*time.Second {
+					t.Errorf("expected 20 worker threads and a timeout of 30s, got %d and %s", c.WorkerThreads, c.Timeout)
+				}
+			},
+		},
+		{
+			sidecar: "provisioner",
+			args: []string{
+				"--timeout=30s",
+				"--worker-threads=20",
+				"--default-fstype=ext4",
+				"--volume-name-prefix=pv",
+				"--volume-name-uuid-length=8",
+				"--cloning-protection-threads=2",
+				"--capacity-threads=2",
+				"--strict-topology",
+				"--immediate-topology=false",
+				"--extra-create-metadata",
+				"--enable-pprof",
+				"--enable-capacity",
+				"--capacity-ownerref-level=2",
+				"--capacity-poll-interval=2m",
+				"--capacity-for-immediate-binding",
+				"--kube-api-capacity-qps=2",
+				"--kube-api-capacity-burst=4",
+				"--node-deployment",
+				"--node-deployment-immediate-binding=false",
+				"--node-deployment-base-delay=10s",
+				"--node-deployment-max-delay=30s",
+				"--controller-publish-readonly",
+				"--prevent-volume-mode-conversion=false",
+			},
+			check: func(t *testing.T) {
+				if c := config.Configuration.AttacherConfiguration; c.WorkerThreads != 20 || c.DefaultFSType != "ext4" {
+					t.Errorf("expected 20 worker threads and the ext4 default fstype, got %d and %q", c.WorkerThreads, c.DefaultFSType)
+				}
+				if c := config.Configuration.ProvisionerConfiguration; c.VolumeNamePrefix != "pv" || !c.StrictTopology {
+					t.Errorf("expected the pv volume name prefix with strict topology, got %q and %t", c.VolumeNamePrefix, c.StrictTopology)
+				}
+			},
+		},
+		{
+			sidecar: "resizer",
+			args: []string{
+				// The standalone sidecars parse their flags with the
+				// standard library, which accepts a single dash.
+				"-timeout=30s",
+				"-workers=20",
+				"--resync-period=20m",
+				"--handle-volume-inuse-error=false",
+				"--extra-modify-metadata",
+			},
+			check: func(t *testing.T) {
+				if c := config.Configuration.AttacherConfiguration; c.WorkerThreads != 20 || c.Timeout != 30*time.Second {
+					t.Errorf("expected 20 worker threads and a timeout of 30s, got %d and %s", c.WorkerThreads, c.Timeout)
+				}
+				if config.Configuration.Resync != 20*time.Minute {
+					t.Errorf("expected a resync period of 20m, got %s", config.Configuration.Resync)
+				}
+				if c := config.Configuration.ResizerConfiguration; c.HandleVolumeInUseError || !c.ExtraModifyMetadata {
+					t.Errorf("expected --handle-volume-inuse-error=false and --extra-modify-metadata, got %+v", c)
+				}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.sidecar, func(t *testing.T) {
+			goflags := goflag.NewFlagSet("csi-"+test.sidecar, goflag.ContinueOnError)
+			flags := flag.NewFlagSet("csi-"+test.sidecar, flag.ContinueOnError)
+			registerFlags(goflags, flags, test.sidecar)
+
+			args, legacy, err := config.ExtractLegacyFlags(flags, config.NormalizeArgs(append(common, test.args...)))
+			if err != nil {
+				t.Fatalf("ExtractLegacyFlags failed: %v", err)
+			}
+			if len(legacy) > 0 {
+				t.Errorf("expected every flag of csi-%s to be registered, got the legacy flags %+v", test.sidecar, legacy)
+			}
+			if err := flags.Parse(args); err != nil {
+				t.Fatalf("failed to parse the flags of csi-%s: %v", test.sidecar, err)
+			}
+			test.check(t)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"fmt"
+
+	dto "github.com/prometheus/client_model/go"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	basemetrics "k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+// Apply validates the metrics options and applies them to the component-base
+// metrics framework shared by every sidecar in the AIO binary.
+//
+// It must be called before the sidecars start: the registry owned by each
+// sidecar's CSIMetricsManager is created afterwards and honors
+// --disabled-metrics when its collectors are registered, while label
+// allow-lists are resolved by every metric vector on first use.
+// Collectors that were registered to the legacy registry during package
+// initialization (process, Go runtime, workqueue and leader election
+// metrics) are filtered out when the legacy registry is gathered.
+func Apply(o *basemetrics.Options) error {
+	if o == nil {
+		return nil
+	}
+	if errs := o.Validate(); len(errs) > 0 {
+		return fmt.Errorf("invalid metrics configuration: %w", utilerrors.NewAggregate(errs))
+	}
+
+	if len(o.ShowHiddenMetricsForVersion) > 0 {
+		basemetrics.SetShowHidden()
+	}
+	basemetrics.SetDisabledMetrics(o.DisabledMetrics)
+	// basemetrics.Apply complains about an empty manifest when no allow-list
+	// is set, only apply the source that was actually configured.
+	switch {
+	case len(o.AllowListMapping) > 0:
+		basemetrics.SetLabelAllowList(o.AllowListMapping)
+	case o.AllowListMappingManifest != "":
+		basemetrics.SetLabelAllowListFromManifest(o.AllowListMappingManifest)
+	}
+
+	if len(o.DisabledMetrics) > 0 {
+		legacyregistry.DefaultGatherer = NewFilteredGatherer(legacyregistry.DefaultGatherer, o.DisabledMetrics)
+	}
+	return nil
+}
+
+// filteredGatherer drops the metric families that were disabled after their
+// collectors had already been registered.
+type filteredGatherer struct {
+	gatherer basemetrics.Gatherer
+	disabled sets.Set[string]
+}
+
+var _ basemetrics.Gatherer = &filteredGatherer{}
+
+// NewFilteredGatherer returns a Gatherer that omits the metric families
+// listed in disabled from the output of gatherer.
+func NewFilteredGatherer(gatherer basemetrics.Gatherer, disabled []string) basemetrics.Gatherer {
+	return &filteredGatherer{
+		gatherer: gatherer,
+		disabled: sets.New(disabled...),
+	}
+}
+
+// Gather implements prometheus.Gatherer.
+func (f *filteredGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := f.gatherer.Gather()
+	filtered := families[:0]
+	for _, family := range families {
+		if f.disabled.Has(family.GetName()) {
+			continue
+		}
+		filtered = append(filtered, family)
+	}
+	return filtered, err
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrate
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+)
+
+// aioFlag is the AIO flag a sidecar flag translates to.
+type aioFlag struct {
+	name string
+	// shared is set when the AIO flag applies to every sidecar although
+	// the standalone flag only applied to this one.
+	shared bool
+}
+
+// sidecarFlags are the flags of a standalone sidecar that have an AIO
+// equivalent under another name, or whose AIO equivalent is shared.
+var sidecarFlags = map[string]map[string]aioFlag{
+	"attacher": {
+		"resync":               {name: "resync"},
+		"worker-threads":       {name: "attacher-worker-threads"},
+		"default-fstype":       {name: "attacher-default-fstype"},
+		"max-entries":          {name: "attacher-max-entries"},
+		"reconcile-sync":       {name: "attacher-reconcile-sync"},
+		"max-grpc-log-length":  {name: "attacher-max-grpc-log-length"},
+		"timeout":              {name: "attacher-timeout"},
+		"retry-interval-start": {name: "retry-interval-start"},
+		"retry-interval-max":   {name: "retry-interval-max"},
+	},
+	"provisioner": {
+		"resync":                            {name: "resync"},
+		"retry-interval-start":              {name: "retry-interval-start"},
+		"retry-interval-max":                {name: "retry-interval-max"},
+		"kube-api-capacity-qps":             {name: "provisioner-kube-api-capacity-qps"},
+		"kube-api-capacity-burst":           {name: "provisioner-kube-api-capacity-burst"},
+		"volume-name-prefix":                {name: "provisioner-volume-name-prefix"},
+		"volume-name-uuid-length":           {name: "provisioner-volume-name-uuid-length"},
+		"cloning-protection-threads":        {name: "provisioner-cloning-protection-threads"},
+		"capacity-threads":                  {name: "provisioner-capacity-threads"},
+		"strict-topology":                   {name: "provisioner-strict-topology"},
+		"immediate-topology":                {name: "provisioner-immediate-topology"},
+		"extra-create-metadata":             {name: "provisioner-extra-create-metadata"},
+		"enable-pprof":                      {name: "provisioner-enable-pprof"},
+		"enable-capacity":                   {name: "provisioner-enable-capacity"},
+		"capacity-for-immediate-binding":    {name: "provisioner-capacity-for-immediate-binding"},
+		"capacity-poll-interval":            {name: "provisioner-capacity-poll-interval"},
+		"capacity-ownerref-level":           {name: "provisioner-capacity-ownerref-level"},
+		"node-deployment":                   {name: "provisioner-node-deployment"},
+		"node-deployment-immediate-binding": {name: "provisioner-node-deployment-immediate-binding"},
+		"node-deployment-base-delay":        {name: "provisioner-node-deployment-base-delay"},
+		"node-deployment-max-delay":         {name: "provisioner-node-deployment-max-delay"},
+		"controller-publish-readonly":       {name: "provisioner-controller-publish-readonly"},
+		"prevent-volume-mode-conversion":    {name: "provisioner-prevent-volume-mode-conversion"},
+		// The AIO binary runs every sidecar with the attacher worker
+		// threads, timeout and default filesystem type.
+		"worker-threads": {name: "attacher-worker-threads", shared: true},
+		"timeout":        {name: "attacher-timeout", shared: true},
+		"default-fstype": {name: "attacher-default-fstype", shared: true},
+	},
+	"resizer": {
+		"resync-period":             {name: "resync"},
+		"retry-interval-start":      {name: "retry-interval-start"},
+		"retry-interval-max":        {name: "retry-interval-max"},
+		"handle-volume-inuse-error": {name: "resizer-handle-volume-inuse-error"},
+		"extra-modify-metadata":     {name: "resizer-extra-modify-metadata"},
+		"workers":                   {name: "attacher-worker-threads", shared: true},
+		"timeout":                   {name: "attacher-timeout", shared: true},
+	},
+}
+
+// Flags merged instead of translated one to one.
+const (
+	featureGatesFlag = "feature-gates"
+	verbosityFlag    = "v"
+)
+
+// translateFlag returns the AIO flag of a flag of sidecar. The flags the
+// AIO binary has unprefixed, e.g. --csi-address or the logging flags, are
+// kept as they are.
+func translateFlag(sidecar, name string, aioFlags *flag.FlagSet) (aioFlag, bool) {
+	if aio, ok := sidecarFlags[sidecar][name]; ok {
+		return aio, true
+	}
+	if aioFlags.Lookup(name) != nil {
+		return aioFlag{name: name}, true
+	}
+	return aioFlag{}, false
+}
+
+// isBoolFlag returns whether a flag of sidecar takes no value, like its AIO
+// flag. The flags without AIO equivalent are assumed to take one.
+func isBoolFlag(sidecar, name string, aioFlags *flag.FlagSet) bool {
+	aio, ok := translateFlag(sidecar, name, aioFlags)
+	if !ok {
+		return false
+	}
+	f := aioFlags.Lookup(aio.name)
+	return f != nil && f.NoOptDefVal != ""
+}
+
+// arg is a parsed container argument.
+type arg struct {
+	name  string
+	value string
+}
+
+// parseArgs splits container args like --name=value, --name value and
+// --bool-flag. The sidecars have no positional arguments.
+func parseArgs(args []string, isBool func(name string) bool) ([]arg, error) {
+	var parsed []arg
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if !strings.HasPrefix(a, "-") {
+			return nil, fmt.Errorf("unexpected positional argument %q", a)
+		}
+		name := strings.TrimLeft(a, "-")
+		if n, value, ok := strings.Cut(name, "="); ok {
+			parsed = append(parsed, arg{name: n, value: value})
+			continue
+		}
+		if !isBool(name) && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+			parsed = append(parsed, arg{name: name, value: args[i+1]})
+			i++
+			continue
+		}
+		parsed = append(parsed, arg{name: name, value: "true"})
+	}
+	return parsed, nil
+}
+
+// parseFeatureGates parses --feature-gates=A=true,B=false.
+func parseFeatureGates(value string) (map[string]string, error) {
+	gates := map[string]string{}
+	for _, gate := range strings.Split(value, ",") {
+		gate = strings.TrimSpace(gate)
+		if gate == "" {
+			continue
+		}
+		name, enabled, ok := strings.Cut(gate, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid feature gate %q", gate)
+		}
+		if _, err := strconv.ParseBool(enabled); err != nil {
+			return nil, fmt.Errorf("invalid value of feature gate %q: %w", name, err)
+		}
+		gates[strings.TrimSpace(name)] = strings.TrimSpace(enabled)
+	}
+	return gates, nil
+}
+
+func formatFeatureGates(gates map[string]string) string {
+	names := make([]string, 0, len(gates))
+	for name := range gates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, name+"="+gates[name])
+	}
+	return strings.Join(pairs, ",")
+}
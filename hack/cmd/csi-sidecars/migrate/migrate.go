@@ -0,0 +1,442 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package migrate implements `csi-sidecars migrate`, which rewrites the
+// Deployments and StatefulSets of a CSI driver to run the csi-attacher,
+// csi-provisioner and csi-resizer containers as one csi-sidecars container.
+package migrate
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	goflag "flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// sidecars are the standalone sidecars merged into the AIO container, in
+// the order of their --controllers entry.
+var sidecars = []string{"attacher", "provisioner", "resizer"}
+
+type options struct {
+	file  string
+	image string
+	name  string
+	// aioFlags are the flags of the AIO binary.
+	aioFlags *flag.FlagSet
+}
+
+// Run runs `csi-sidecars migrate` with args, the arguments after `migrate`,
+// and returns the exit code. aioFlags are the flags of the AIO binary, the
+// sidecar flags it has under the same name are kept as they are. Warnings
+// are printed to stderr.
+func Run(args []string, aioFlags *flag.FlagSet) int {
+	flags := goflag.NewFlagSet("csi-sidecars migrate", goflag.ContinueOnError)
+	o := options{aioFlags: aioFlags}
+	flags.StringVar(&o.file, "f", "-", "The YAML file with the Deployments or StatefulSets to migrate, - reads stdin.")
+	flags.StringVar(&o.image, "image", "", "The image of the csi-sidecars container, required.")
+	flags.StringVar(&o.name, "name", "csi-sidecars", "The name of the csi-sidecars container.")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: csi-sidecars migrate --image <image> [-f manifest.yaml]")
+		fmt.Fprintln(flags.Output(), "\nPrints the manifest with the csi-attacher, csi-provisioner and csi-resizer containers merged into one csi-sidecars container.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, goflag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if o.image == "" {
+		fmt.Fprintln(os.Stderr, "--image is required")
+		return 2
+	}
+
+	in := io.Reader(os.Stdin)
+	if o.file != "-" {
+		f, err := os.Open(o.file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer f.Close()
+		in = f
+	}
+	warnings, err := migrate(in, os.Stdout, o)
+	for _, w := range warnings {
+		fmt.Fprintln(os.Stderr, "warning:", w)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// migrate rewrites every Deployment and StatefulSet of the YAML stream in,
+// the other documents are copied unchanged.
+func migrate(in io.Reader, out io.Writer, o options) ([]string, error) {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(in))
+	var warnings []string
+	first := true
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return warnings, nil
+		}
+		if err != nil {
+			return warnings, err
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		migrated, docWarnings, err := migrateDocument(doc, o)
+		if err != nil {
+			return warnings, err
+		}
+		warnings = append(warnings, docWarnings...)
+		if !first {
+			fmt.Fprintln(out, "---")
+		}
+		first = false
+		if _, err := out.Write(migrated); err != nil {
+			return warnings, err
+		}
+	}
+}
+
+func migrateDocument(doc []byte, o options) ([]byte, []string, error) {
+	obj := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal(doc, &obj.Object); err != nil {
+		return nil, nil, err
+	}
+
+	var typed runtime.Object
+	var podSpec *corev1.PodSpec
+	switch obj.GetKind() {
+	case "Deployment":
+		deployment := &appsv1.Deployment{}
+		typed, podSpec = deployment, &deployment.Spec.Template.Spec
+	case "StatefulSet":
+		statefulSet := &appsv1.StatefulSet{}
+		typed, podSpec = statefulSet, &statefulSet.Spec.Template.Spec
+	default:
+		return doc, nil, nil
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, typed); err != nil {
+		return nil, nil, fmt.Errorf("%s %s: %w", obj.GetKind(), obj.GetName(), err)
+	}
+
+	warnings, changed, err := mergeSidecars(podSpec, o)
+	prefix := fmt.Sprintf("%s %s: ", obj.GetKind(), obj.GetName())
+	for i := range warnings {
+		warnings[i] = prefix + warnings[i]
+	}
+	if err != nil {
+		return nil, warnings, fmt.Errorf("%s%w", prefix, err)
+	}
+	if !changed {
+		return doc, warnings, nil
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(typed)
+	if err != nil {
+		return nil, warnings, err
+	}
+	// Drop the fields the typed round trip adds.
+	delete(content, "status")
+	unstructured.RemoveNestedField(content, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(content, "spec", "template", "metadata", "creationTimestamp")
+	migrated, err := yaml.Marshal(content)
+	return migrated, warnings, err
+}
+
+// sidecarOf returns the sidecar a container runs, e.g. provisioner for a
+// csi-provisioner container or image.
+func sidecarOf(c corev1.Container) string {
+	image := c.Image
+	if i := strings.LastIndex(image, "/"); i >= 0 {
+		image = image[i+1:]
+	}
+	image, _, _ = strings.Cut(image, ":")
+	image, _, _ = strings.Cut(image, "@")
+	for _, sidecar := range sidecars {
+		if c.Name == "csi-"+sidecar || image == "csi-"+sidecar {
+			return sidecar
+		}
+	}
+	return ""
+}
+
+// mergeSidecars replaces the sidecar containers of spec with one AIO
+// container placed where the first one was.
+func mergeSidecars(spec *corev1.PodSpec, o options) ([]string, bool, error) {
+	found := map[string]corev1.Container{}
+	position := -1
+	var containers []corev1.Container
+	for _, c := range spec.Containers {
+		sidecar := sidecarOf(c)
+		if sidecar == "" {
+			containers = append(containers, c)
+			continue
+		}
+		if _, ok := found[sidecar]; ok {
+			return nil, false, fmt.Errorf("more than one csi-%s container", sidecar)
+		}
+		found[sidecar] = c
+		if position < 0 {
+			position = len(containers)
+		}
+	}
+	if len(found) == 0 {
+		return nil, false, nil
+	}
+
+	m := newMerger(o)
+	for _, sidecar := range sidecars {
+		if c, ok := found[sidecar]; ok {
+			if err := m.add(sidecar, c); err != nil {
+				return m.warnings, false, fmt.Errorf("container %s: %w", c.Name, err)
+			}
+		}
+	}
+	spec.Containers = append(containers[:position], append([]corev1.Container{m.result()}, containers[position:]...)...)
+	return m.warnings, true, nil
+}
+
+// merger accumulates the sidecar containers into the AIO container.
+type merger struct {
+	merged       corev1.Container
+	aioFlags     *flag.FlagSet
+	controllers  []string
+	args         map[string]string
+	argSources   map[string]string
+	featureGates map[string]string
+	// verbosity is the -v of every sidecar that sets it.
+	verbosity map[string]int
+	warnings  []string
+}
+
+func newMerger(o options) *merger {
+	return &merger{
+		merged: corev1.Container{
+			Name:  o.name,
+			Image: o.image,
+		},
+		aioFlags:     o.aioFlags,
+		args:         map[string]string{},
+		argSources:   map[string]string{},
+		featureGates: map[string]string{},
+		verbosity:    map[string]int{},
+	}
+}
+
+func (m *merger) warnf(format string, args ...any) {
+	m.warnings = append(m.warnings, fmt.Sprintf(format, args...))
+}
+
+func (m *merger) add(sidecar string, c corev1.Container) error {
+	m.controllers = append(m.controllers, sidecar)
+	if len(c.Command) > 0 {
+		m.warnf("the command %q of container %s is dropped", strings.Join(c.Command, " "), c.Name)
+	}
+
+	args, err := parseArgs(c.Args, func(name string) bool {
+		return isBoolFlag(sidecar, name, m.aioFlags)
+	})
+	if err != nil {
+		return err
+	}
+	for _, a := range args {
+		switch a.name {
+		case featureGatesFlag:
+			gates, err := parseFeatureGates(a.value)
+			if err != nil {
+				return err
+			}
+			// The AIO binary has feature gates per controller.
+			for name, enabled := range gates {
+				m.featureGates[sidecar+":"+name] = enabled
+			}
+		case verbosityFlag:
+			level, err := strconv.Atoi(a.value)
+			if err != nil {
+				return fmt.Errorf("invalid -v=%s", a.value)
+			}
+			m.verbosity[sidecar] = level
+		default:
+			m.addArg(sidecar, a)
+		}
+	}
+
+	m.mergeEnv(c)
+	m.mergeVolumeMounts(c)
+	m.mergePorts(c)
+	m.mergeResources(c)
+	mergeSame(m, c, "securityContext", &m.merged.SecurityContext, c.SecurityContext)
+	mergeSame(m, c, "livenessProbe", &m.merged.LivenessProbe, c.LivenessProbe)
+	mergeSame(m, c, "readinessProbe", &m.merged.ReadinessProbe, c.ReadinessProbe)
+	if m.merged.ImagePullPolicy == "" {
+		m.merged.ImagePullPolicy = c.ImagePullPolicy
+	}
+	return nil
+}
+
+// addArg translates a flag of sidecar, a flag without AIO equivalent is
+// dropped with a warning.
+func (m *merger) addArg(sidecar string, a arg) {
+	aio, ok := translateFlag(sidecar, a.name, m.aioFlags)
+	if !ok {
+		m.warnf("--%s of csi-%s has no AIO equivalent and is dropped", a.name, sidecar)
+		return
+	}
+	if existing, ok := m.args[aio.name]; ok {
+		if existing != a.value {
+			m.warnf("--%s=%s of csi-%s conflicts with --%s=%s of csi-%s, keeping the value of csi-%s", a.name, a.value, sidecar, aio.name, existing, m.argSources[aio.name], m.argSources[aio.name])
+		}
+		return
+	}
+	if aio.shared {
+		m.warnf("--%s of csi-%s becomes --%s, which applies to every sidecar", a.name, sidecar, aio.name)
+	}
+	m.args[aio.name] = a.value
+	m.argSources[aio.name] = sidecar
+}
+
+func (m *merger) mergeEnv(c corev1.Container) {
+	for _, env := range c.Env {
+		i := -1
+		for j := range m.merged.Env {
+			if m.merged.Env[j].Name == env.Name {
+				i = j
+			}
+		}
+		if i < 0 {
+			m.merged.Env = append(m.merged.Env, env)
+		} else if !reflect.DeepEqual(m.merged.Env[i], env) {
+			m.warnf("environment variable %s of container %s conflicts with another sidecar, keeping the first", env.Name, c.Name)
+		}
+	}
+	m.merged.EnvFrom = append(m.merged.EnvFrom, c.EnvFrom...)
+}
+
+func (m *merger) mergeVolumeMounts(c corev1.Container) {
+	for _, mount := range c.VolumeMounts {
+		i := -1
+		for j := range m.merged.VolumeMounts {
+			if m.merged.VolumeMounts[j].MountPath == mount.MountPath {
+				i = j
+			}
+		}
+		if i < 0 {
+			m.merged.VolumeMounts = append(m.merged.VolumeMounts, mount)
+		} else if !reflect.DeepEqual(m.merged.VolumeMounts[i], mount) {
+			m.warnf("volume mount %s of container %s conflicts with another sidecar, keeping the first", mount.MountPath, c.Name)
+		}
+	}
+}
+
+func (m *merger) mergePorts(c corev1.Container) {
+	for _, port := range c.Ports {
+		duplicate := false
+		for _, existing := range m.merged.Ports {
+			if existing.ContainerPort == port.ContainerPort && existing.Protocol == port.Protocol {
+				duplicate = true
+			}
+		}
+		if !duplicate {
+			m.merged.Ports = append(m.merged.Ports, port)
+		}
+	}
+	if len(c.Ports) > 0 && len(m.controllers) > 1 {
+		m.warnf("the AIO container serves every sidecar on a single --http-endpoint, check the ports of container %s", c.Name)
+	}
+}
+
+// mergeResources sums the requests and limits of the sidecars.
+func (m *merger) mergeResources(c corev1.Container) {
+	sum := func(total *corev1.ResourceList, list corev1.ResourceList) {
+		for name, quantity := range list {
+			if *total == nil {
+				*total = corev1.ResourceList{}
+			}
+			q := (*total)[name]
+			q.Add(quantity)
+			(*total)[name] = q
+		}
+	}
+	sum(&m.merged.Resources.Requests, c.Resources.Requests)
+	sum(&m.merged.Resources.Limits, c.Resources.Limits)
+}
+
+// mergeSame keeps the first value of a field and warns when another
+// sidecar sets a different one.
+func mergeSame[T any](m *merger, c corev1.Container, field string, merged **T, value *T) {
+	switch {
+	case value == nil:
+	case *merged == nil:
+		*merged = value
+	case !reflect.DeepEqual(*merged, value):
+		m.warnf("the %s of container %s differs from another sidecar, keeping the first", field, c.Name)
+	}
+}
+
+func (m *merger) result() corev1.Container {
+	c := m.merged
+	c.Args = []string{"--controllers=" + strings.Join(m.controllers, ",")}
+	names := make([]string, 0, len(m.args))
+	for name := range m.args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		c.Args = append(c.Args, "--"+name+"="+m.args[name])
+	}
+	if len(m.featureGates) > 0 {
+		c.Args = append(c.Args, "--"+featureGatesFlag+"="+formatFeatureGates(m.featureGates))
+	}
+	if len(m.verbosity) > 0 {
+		// -v is the highest level since the logs of the global klog
+		// functions only follow -v, the sidecars with a lower one keep it
+		// with --<controller>-v. A sidecar without -v logs at level 0.
+		highest := m.verbosity[m.controllers[0]]
+		for _, controller := range m.controllers {
+			highest = max(highest, m.verbosity[controller])
+		}
+		c.Args = append(c.Args, "-"+verbosityFlag+"="+strconv.Itoa(highest))
+		for _, controller := range m.controllers {
+			if level := m.verbosity[controller]; level < highest {
+				c.Args = append(c.Args, "--"+controller+"-"+verbosityFlag+"="+strconv.Itoa(level))
+			}
+		}
+	}
+	return c
+}
@@ -0,0 +1,449 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrate
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	flag "github.com/spf13/pflag"
+	corev1 "k8s.io/api/core/v1"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// hostpathPlugin is the StatefulSet of the CSI hostpath driver,
+// deploy/kubernetes-latest/hostpath/csi-hostpath-plugin.yaml of
+// csi-driver-host-path with the containers it runs besides the sidecars
+// trimmed to the driver and the liveness probe.
+const hostpathPlugin = `kind: StatefulSet
+apiVersion: apps/v1
+metadata:
+  name: csi-hostpathplugin
+  namespace: default
+spec:
+  serviceName: csi-hostpathplugin
+  replicas: 1
+  selector:
+    matchLabels:
+      app.kubernetes.io/name: csi-hostpathplugin
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: csi-hostpathplugin
+    spec:
+      serviceAccountName: csi-hostpathplugin-sa
+      containers:
+        - name: hostpath
+          image: registry.k8s.io/sig-storage/hostpathplugin:v1.15.0
+          args:
+            - "--drivername=hostpath.csi.k8s.io"
+            - "--v=5"
+            - "--endpoint=$(CSI_ENDPOINT)"
+            - "--nodeid=$(KUBE_NODE_NAME)"
+          env:
+            - name: CSI_ENDPOINT
+              value: unix:///csi/csi.sock
+          securityContext:
+            privileged: true
+          volumeMounts:
+            - mountPath: /csi
+              name: socket-dir
+        - name: csi-attacher
+          image: registry.k8s.io/sig-storage/csi-attacher:v4.8.0
+          args:
+            - --v=5
+            - --csi-address=/csi/csi.sock
+          securityContext:
+            privileged: true
+          volumeMounts:
+          - mountPath: /csi
+            name: socket-dir
+        - name: csi-provisioner
+          image: registry.k8s.io/sig-storage/csi-provisioner:v5.2.0
+          args:
+            - -v=5
+            - --csi-address=/csi/csi.sock
+            - --feature-gates=Topology=true
+          securityContext:
+            privileged: true
+          volumeMounts:
+            - mountPath: /csi
+              name: socket-dir
+        - name: csi-resizer
+          image: registry.k8s.io/sig-storage/csi-resizer:v1.13.1
+          args:
+            - -v=5
+            - -csi-address=/csi/csi.sock
+          securityContext:
+            privileged: true
+          volumeMounts:
+            - mountPath: /csi
+              name: socket-dir
+        - name: liveness-probe
+          image: registry.k8s.io/sig-storage/livenessprobe:v2.15.0
+          args:
+          - --csi-address=/csi/csi.sock
+          - --health-port=9898
+          volumeMounts:
+          - mountPath: /csi
+            name: socket-dir
+      volumes:
+        - hostPath:
+            path: /var/lib/kubelet/plugins/csi-hostpath
+            type: DirectoryOrCreate
+          name: socket-dir
+`
+
+// provisionerDeployment is deploy/kubernetes/deployment.yaml of the
+// external-provisioner.
+const provisionerDeployment = `kind: Deployment
+apiVersion: apps/v1
+metadata:
+  name: csi-provisioner
+spec:
+  replicas: 3
+  selector:
+    matchLabels:
+      app: csi-provisioner
+  template:
+    metadata:
+      labels:
+        app: csi-provisioner
+    spec:
+      serviceAccount: csi-provisioner
+      containers:
+        - name: csi-provisioner
+          image: registry.k8s.io/sig-storage/csi-provisioner:v5.2.0
+          args:
+            - "--csi-address=$(ADDRESS)"
+            - "--leader-election"
+            - "--http-endpoint=:8080"
+          env:
+            - name: ADDRESS
+              value: /var/lib/csi/sockets/pluginproxy/mock.socket
+          imagePullPolicy: "IfNotPresent"
+          ports:
+            - containerPort: 8080
+              name: http-endpoint
+              protocol: TCP
+          livenessProbe:
+            failureThreshold: 1
+            httpGet:
+              path: /healthz/leader-election
+              port: http-endpoint
+            initialDelaySeconds: 10
+            timeoutSeconds: 10
+            periodSeconds: 20
+          volumeMounts:
+            - name: socket-dir
+              mountPath: /var/lib/csi/sockets/pluginproxy/
+        - name: mock-driver
+          image: registry.k8s.io/sig-storage/mock-driver:v4.1.0
+          env:
+            - name: CSI_ENDPOINT
+              value: /var/lib/csi/sockets/pluginproxy/mock.socket
+          volumeMounts:
+            - name: socket-dir
+              mountPath: /var/lib/csi/sockets/pluginproxy/
+      volumes:
+        - name: socket-dir
+          emptyDir:
+`
+
+// customizedController runs the attacher and the resizer with different
+// verbosity, resources and flags.
+const customizedController = `kind: Deployment
+apiVersion: apps/v1
+metadata:
+  name: csi-driver-controller
+spec:
+  selector:
+    matchLabels:
+      app: csi-driver-controller
+  template:
+    metadata:
+      labels:
+        app: csi-driver-controller
+    spec:
+      containers:
+        - name: csi-attacher
+          image: registry.k8s.io/sig-storage/csi-attacher:v4.8.0
+          args:
+            - -v=2
+            - --kube-api-qps=5
+          resources:
+            requests:
+              cpu: 10m
+              memory: 20Mi
+        - name: csi-resizer
+          image: registry.k8s.io/sig-storage/csi-resizer:v1.13.1
+          args:
+            - -v=5
+            - --kube-api-qps=10
+            - --workers=20
+            - --log-flush-frequency=5s
+            - --unknown-flag=1
+          resources:
+            requests:
+              cpu: 20m
+            limits:
+              memory: 100Mi
+`
+
+// testAIOFlags returns a subset of the flags of the AIO binary.
+func testAIOFlags() *flag.FlagSet {
+	flags := flag.NewFlagSet("csi-sidecars", flag.ContinueOnError)
+	flags.String("csi-address", "", "")
+	flags.Bool("leader-election", false, "")
+	flags.String("http-endpoint", "", "")
+	flags.Float64("kube-api-qps", 5, "")
+	flags.Duration("log-flush-frequency", 5*time.Second, "")
+	flags.Duration("resync", 10*time.Minute, "")
+	flags.Int("attacher-worker-threads", 10, "")
+	flags.Duration("attacher-timeout", 15*time.Second, "")
+	flags.Bool("provisioner-strict-topology", false, "")
+	flags.Bool("resizer-handle-volume-inuse-error", true, "")
+	return flags
+}
+
+// podSpecs returns the pod specs of the Deployments and StatefulSets of a
+// YAML stream.
+func podSpecs(t *testing.T, data []byte) []corev1.PodSpec {
+	t.Helper()
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	var specs []corev1.PodSpec
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return specs
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		var obj struct {
+			Spec struct {
+				Template corev1.PodTemplateSpec `json:"template"`
+			} `json:"spec"`
+		}
+		if err := yaml.Unmarshal(doc, &obj); err != nil {
+			t.Fatalf("failed to unmarshal the migrated manifest: %v\n%s", err, doc)
+		}
+		specs = append(specs, obj.Spec.Template.Spec)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name               string
+		manifest           string
+		expectedContainers []string
+		expectedArgs       []string
+		expectedWarnings   []string
+		expectedRequests   map[corev1.ResourceName]string
+		expectedLimits     map[corev1.ResourceName]string
+	}{
+		{
+			name:               "hostpath plugin",
+			manifest:           hostpathPlugin,
+			expectedContainers: []string{"hostpath", "csi-sidecars", "liveness-probe"},
+			expectedArgs: []string{
+				"--controllers=attacher,provisioner,resizer",
+				"--csi-address=/csi/csi.sock",
+				"--feature-gates=provisioner:Topology=true",
+				"-v=5",
+			},
+		},
+		{
+			name:               "provisioner deployment",
+			manifest:           provisionerDeployment,
+			expectedContainers: []string{"csi-sidecars", "mock-driver"},
+			expectedArgs: []string{
+				"--controllers=provisioner",
+				"--csi-address=$(ADDRESS)",
+				"--http-endpoint=:8080",
+				"--leader-election=true",
+			},
+		},
+		{
+			name:               "customized sidecars",
+			manifest:           customizedController,
+			expectedContainers: []string{"csi-sidecars"},
+			expectedArgs: []string{
+				"--controllers=attacher,resizer",
+				"--attacher-worker-threads=20",
+				"--kube-api-qps=5",
+				"--log-flush-frequency=5s",
+				"-v=5",
+				"--attacher-v=2",
+			},
+			expectedWarnings: []string{
+				"Deployment csi-driver-controller: --kube-api-qps=10 of csi-resizer conflicts with --kube-api-qps=5 of csi-attacher, keeping the value of csi-attacher",
+				"Deployment csi-driver-controller: --workers of csi-resizer becomes --attacher-worker-threads, which applies to every sidecar",
+				"Deployment csi-driver-controller: --unknown-flag of csi-resizer has no AIO equivalent and is dropped",
+			},
+			expectedRequests: map[corev1.ResourceName]string{corev1.ResourceCPU: "30m", corev1.ResourceMemory: "20Mi"},
+			expectedLimits:   map[corev1.ResourceName]string{corev1.ResourceMemory: "100Mi"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+			warnings, err := migrate(strings.NewReader(test.manifest), &out, options{
+				image:    "registry.k8s.io/sig-storage/csi-sidecars:v0.1.0",
+				name:     "csi-sidecars",
+				aioFlags: testAIOFlags(),
+			})
+			if err != nil {
+				t.Fatalf("migrate failed: %v", err)
+			}
+			if !reflect.DeepEqual(warnings, test.expectedWarnings) {
+				t.Errorf("expected the warnings %q, got %q", test.expectedWarnings, warnings)
+			}
+
+			specs := podSpecs(t, out.Bytes())
+			if len(specs) != 1 {
+				t.Fatalf("expected one migrated object, got %d:\n%s", len(specs), out.String())
+			}
+			var names []string
+			var aio *corev1.Container
+			for i, c := range specs[0].Containers {
+				names = append(names, c.Name)
+				if c.Name == "csi-sidecars" {
+					aio = &specs[0].Containers[i]
+				}
+			}
+			if !reflect.DeepEqual(names, test.expectedContainers) {
+				t.Fatalf("expected the containers %v, got %v", test.expectedContainers, names)
+			}
+			if !reflect.DeepEqual(aio.Args, test.expectedArgs) {
+				t.Errorf("expected the args %q, got %q", test.expectedArgs, aio.Args)
+			}
+			for _, resources := range []struct {
+				kind     string
+				list     corev1.ResourceList
+				expected map[corev1.ResourceName]string
+			}{
+				{"requests", aio.Resources.Requests, test.expectedRequests},
+				{"limits", aio.Resources.Limits, test.expectedLimits},
+			} {
+				actual := map[corev1.ResourceName]string{}
+				for name, quantity := range resources.list {
+					actual[name] = quantity.String()
+				}
+				if len(actual) == 0 && len(resources.expected) == 0 {
+					continue
+				}
+				if !reflect.DeepEqual(actual, resources.expected) {
+					t.Errorf("expected the %s %v, got %v", resources.kind, resources.expected, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		expected      []arg
+		expectedError string
+	}{
+		{
+			name:     "value after the flag",
+			args:     []string{"--csi-address", "/csi/csi.sock", "-v", "5"},
+			expected: []arg{{name: "csi-address", value: "/csi/csi.sock"}, {name: "v", value: "5"}},
+		},
+		{
+			name:     "bool flag followed by a flag",
+			args:     []string{"--leader-election", "--csi-address=/csi/csi.sock"},
+			expected: []arg{{name: "leader-election", value: "true"}, {name: "csi-address", value: "/csi/csi.sock"}},
+		},
+		{
+			name:     "translated bool flag",
+			args:     []string{"--strict-topology", "--timeout", "30s"},
+			expected: []arg{{name: "strict-topology", value: "true"}, {name: "timeout", value: "30s"}},
+		},
+		{
+			name:          "bool flag followed by a positional argument",
+			args:          []string{"--leader-election", "true"},
+			expectedError: `unexpected positional argument "true"`,
+		},
+		{
+			name:          "positional argument",
+			args:          []string{"/csi/csi.sock"},
+			expectedError: `unexpected positional argument "/csi/csi.sock"`,
+		},
+	}
+
+	aioFlags := testAIOFlags()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			args, err := parseArgs(test.args, func(name string) bool {
+				return isBoolFlag("provisioner", name, aioFlags)
+			})
+			if test.expectedError != "" {
+				if err == nil || err.Error() != test.expectedError {
+					t.Fatalf("expected the error %q, got %v", test.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseArgs failed: %v", err)
+			}
+			if !reflect.DeepEqual(args, test.expected) {
+				t.Errorf("expected %+v, got %+v", test.expected, args)
+			}
+		})
+	}
+}
+
+func TestParseFeatureGates(t *testing.T) {
+	tests := []struct {
+		value         string
+		expected      string
+		expectedError bool
+	}{
+		{value: "Topology=true", expected: "Topology=true"},
+		{value: " B=false, A=true ,", expected: "A=true,B=false"},
+		{value: "Topology", expectedError: true},
+		{value: "Topology=maybe", expectedError: true},
+	}
+	for _, test := range tests {
+		gates, err := parseFeatureGates(test.value)
+		if test.expectedError {
+			if err == nil {
+				t.Errorf("%q: expected an error, got %v", test.value, gates)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: parseFeatureGates failed: %v", test.value, err)
+			continue
+		}
+		if actual := formatFeatureGates(gates); actual != test.expected {
+			t.Errorf("%q: expected %q, got %q", test.value, test.expected, actual)
+		}
+	}
+}
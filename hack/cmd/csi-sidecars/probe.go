@@ -0,0 +1,290 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/kubernetes-csi/csi-lib-utils/connection"
+	csimetrics "github.com/kubernetes-csi/csi-lib-utils/metrics"
+	"github.com/kubernetes-csi/csi-lib-utils/rpc"
+	ctrl "github.com/kubernetes-csi/csi-sidecars/pkg/provisioner/pkg/controller"
+	resizercsi "github.com/kubernetes-csi/csi-sidecars/pkg/resizer/pkg/csi"
+	"github.com/kubernetes-csi/csi-sidecars/pkg/resizer/pkg/resizer"
+	"google.golang.org/grpc"
+)
+
+// Exit codes of `csi-sidecars probe`, e.g. for an init container.
+const (
+	probeExitOK = 0
+	// probeExitFailed means the driver couldn't be probed.
+	probeExitFailed = 1
+	probeExitUsage  = 2
+	// probeExitUnsupported means the driver doesn't support one of the
+	// controllers passed with --controllers.
+	probeExitUnsupported = 3
+)
+
+// Handlers a sidecar runs with, see probeControllers.
+const (
+	probeHandlerCSI         = "csi"
+	probeHandlerTrivial     = "trivial"
+	probeHandlerUnsupported = "unsupported"
+)
+
+// probeReport is the output of `csi-sidecars probe`.
+type probeReport struct {
+	DriverName             string            `json:"driverName"`
+	VendorVersion          string            `json:"vendorVersion"`
+	Manifest               map[string]string `json:"manifest,omitempty"`
+	PluginCapabilities     []string          `json:"pluginCapabilities"`
+	ControllerCapabilities []string          `json:"controllerCapabilities"`
+	Controllers            []probeController `json:"controllers"`
+}
+
+// probeController is how the AIO binary would run a sidecar.
+type probeController struct {
+	Name string `json:"name"`
+	// Handler is csi, trivial or unsupported, in which case the sidecar
+	// fails to start.
+	Handler string `json:"handler"`
+	Reason  string `json:"reason"`
+}
+
+// runProbe implements `csi-sidecars probe`, it connects to the CSI driver
+// once, runs the capability checks of the sidecars and prints a report.
+func runProbe(args []string) int {
+	flags := flag.NewFlagSet("csi-sidecars probe", flag.ContinueOnError)
+	csiAddress := flags.String("csi-address", "/run/csi/socket", "Address of the CSI driver socket.")
+	controllers := flags.String("controllers", "attacher,provisioner,resizer", "The controllers that must be supported, the command exits with 3 when one isn't.")
+	timeout := flags.Duration("timeout", 10*time.Second, "Timeout of every CSI call.")
+	wait := flags.Duration("wait", 0, "How long to wait for the driver to be ready. The driver is probed once when 0.")
+	output := flags.String("o", "table", "Output format, table or json.")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return probeExitOK
+		}
+		return probeExitUsage
+	}
+	if *output != "table" && *output != "json" {
+		fmt.Fprintf(os.Stderr, "unknown output format %q\n", *output)
+		return probeExitUsage
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *wait+*timeout)
+	defer cancel()
+	report, err := probeDriver(ctx, *csiAddress, *timeout, *wait > 0)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to probe the CSI driver at %s: %v\n", *csiAddress, err)
+		return probeExitFailed
+	}
+
+	if *output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(report)
+	} else {
+		err = report.print(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return probeExitFailed
+	}
+
+	required := map[string]bool{}
+	for _, name := range strings.Split(*controllers, ",") {
+		required[strings.TrimSpace(name)] = true
+	}
+	for _, c := range report.Controllers {
+		if required[c.Name] && c.Handler == probeHandlerUnsupported {
+			return probeExitUnsupported
+		}
+	}
+	return probeExitOK
+}
+
+func probeDriver(ctx context.Context, address string, timeout time.Duration, wait bool) (*probeReport, error) {
+	csiConn, err := connection.Connect(ctx, address, csimetrics.NewCSIMetricsManager("" /* driverName */))
+	if err != nil {
+		return nil, err
+	}
+	defer csiConn.Close()
+
+	if wait {
+		err = rpc.ProbeForever(ctx, csiConn, timeout)
+	} else {
+		probeCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		var ready bool
+		ready, err = rpc.Probe(probeCtx, csiConn)
+		if err == nil && !ready {
+			err = errors.New("the driver isn't ready")
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// ctx is mostly spent waiting for the driver, the calls get their own
+	// --timeout.
+	callCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	info, err := csi.NewIdentityClient(csiConn).GetPluginInfo(callCtx, &csi.GetPluginInfoRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("GetPluginInfo: %w", err)
+	}
+	report := &probeReport{
+		DriverName:    info.GetName(),
+		VendorVersion: info.GetVendorVersion(),
+		Manifest:      info.GetManifest(),
+	}
+
+	pluginCaps, err := csi.NewIdentityClient(csiConn).GetPluginCapabilities(callCtx, &csi.GetPluginCapabilitiesRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("GetPluginCapabilities: %w", err)
+	}
+	for _, capability := range pluginCaps.GetCapabilities() {
+		if service := capability.GetService(); service != nil {
+			report.PluginCapabilities = append(report.PluginCapabilities, service.GetType().String())
+		}
+		if expansion := capability.GetVolumeExpansion(); expansion != nil {
+			report.PluginCapabilities = append(report.PluginCapabilities, "VOLUME_EXPANSION_"+expansion.GetType().String())
+		}
+	}
+	sort.Strings(report.PluginCapabilities)
+
+	// The same checks the attacher runs before picking its handler.
+	supportsService, err := supportsPluginControllerService(callCtx, csiConn)
+	if err != nil {
+		return nil, fmt.Errorf("GetPluginCapabilities: %w", err)
+	}
+	var controllerCaps rpc.ControllerCapabilitySet
+	if supportsService {
+		controllerCaps, err = rpc.GetControllerCapabilities(callCtx, csiConn)
+		if err != nil {
+			return nil, fmt.Errorf("ControllerGetCapabilities: %w", err)
+		}
+		for capability := range controllerCaps {
+			report.ControllerCapabilities = append(report.ControllerCapabilities, capability.String())
+		}
+		sort.Strings(report.ControllerCapabilities)
+	}
+
+	attacherController, err := probeAttacher(callCtx, csiConn, supportsService)
+	if err != nil {
+		return nil, err
+	}
+	resizerController, err := probeResizer(callCtx, address, timeout, report.DriverName)
+	if err != nil {
+		return nil, err
+	}
+	report.Controllers = []probeController{
+		attacherController,
+		probeProvisioner(csiConn, timeout),
+		resizerController,
+	}
+	return report, nil
+}
+
+// probeAttacher mirrors how attacher_main picks the handler of the attacher.
+func probeAttacher(ctx context.Context, csiConn *grpc.ClientConn, supportsService bool) (probeController, error) {
+	c := probeController{Name: "attacher", Handler: probeHandlerTrivial}
+	if !supportsService {
+		c.Reason = "the plugin doesn't report the CONTROLLER_SERVICE capability"
+		return c, nil
+	}
+	supportsAttach, supportsReadOnly, supportsListVolumesPublishedNodes, supportsSingleNodeMultiWriter, err := supportsControllerCapabilities(ctx, csiConn)
+	if err != nil {
+		return c, fmt.Errorf("ControllerGetCapabilities: %w", err)
+	}
+	if !supportsAttach {
+		c.Reason = "the controller doesn't report the PUBLISH_UNPUBLISH_VOLUME capability"
+		return c, nil
+	}
+	c.Handler = probeHandlerCSI
+	c.Reason = fmt.Sprintf("readOnly=%t listVolumesPublishedNodes=%t singleNodeMultiWriter=%t", supportsReadOnly, supportsListVolumesPublishedNodes, supportsSingleNodeMultiWriter)
+	return c, nil
+}
+
+// probeProvisioner reads the capabilities of the driver like
+// provisioner_main does and checks the ones the provisioner requires.
+func probeProvisioner(csiConn *grpc.ClientConn, timeout time.Duration) probeController {
+	c := probeController{Name: "provisioner", Handler: probeHandlerUnsupported}
+	pluginCaps, controllerCaps, err := ctrl.GetDriverCapabilities(csiConn, timeout)
+	switch {
+	case err != nil:
+		c.Reason = err.Error()
+	case !pluginCaps[csi.PluginCapability_Service_CONTROLLER_SERVICE]:
+		c.Reason = "the plugin doesn't report the CONTROLLER_SERVICE capability"
+	case !controllerCaps[csi.ControllerServiceCapability_RPC_CREATE_DELETE_VOLUME]:
+		c.Reason = "the controller doesn't report the CREATE_DELETE_VOLUME capability"
+	default:
+		c.Handler = probeHandlerCSI
+		c.Reason = fmt.Sprintf("snapshots=%t clones=%t", controllerCaps[csi.ControllerServiceCapability_RPC_CREATE_DELETE_SNAPSHOT], controllerCaps[csi.ControllerServiceCapability_RPC_CLONE_VOLUME])
+	}
+	return c
+}
+
+// probeResizer creates the resizer like resizer_main does, which picks
+// between the CSI resizer and the trivial resizer of drivers that only
+// expand volumes on the node.
+func probeResizer(ctx context.Context, address string, timeout time.Duration, driverName string) (probeController, error) {
+	c := probeController{Name: "resizer", Handler: probeHandlerUnsupported}
+	csiClient, err := resizercsi.New(ctx, address, timeout, csimetrics.NewCSIMetricsManager(driverName))
+	if err != nil {
+		return c, err
+	}
+	defer csiClient.CloseConnection()
+	// The Kubernetes client is only used to resize volumes.
+	r, err := resizer.NewResizerFromClient(csiClient, timeout, nil /* k8sClient */, driverName)
+	switch {
+	case err != nil:
+		c.Reason = err.Error()
+	case r.DriverSupportsControlPlaneExpansion():
+		c.Handler = probeHandlerCSI
+		c.Reason = "the controller reports the EXPAND_VOLUME capability"
+	default:
+		c.Handler = probeHandlerTrivial
+		c.Reason = "the plugin reports volume expansion without the controller EXPAND_VOLUME capability"
+	}
+	return c, nil
+}
+
+func (r *probeReport) print(out io.Writer) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Driver:\t%s\n", r.DriverName)
+	fmt.Fprintf(w, "Vendor version:\t%s\n", r.VendorVersion)
+	fmt.Fprintf(w, "Plugin capabilities:\t%s\n", strings.Join(r.PluginCapabilities, ", "))
+	fmt.Fprintf(w, "Controller capabilities:\t%s\n", strings.Join(r.ControllerCapabilities, ", "))
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "CONTROLLER\tHANDLER\tREASON")
+	for _, c := range r.Controllers {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", c.Name, c.Handler, c.Reason)
+	}
+	return w.Flush()
+}
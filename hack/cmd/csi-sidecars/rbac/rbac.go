@@ -0,0 +1,207 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package rbac checks at startup that every enabled controller has the
+// permissions it needs, instead of failing with forbidden errors in its
+// informers later.
+package rbac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+// Modes of --rbac-check.
+const (
+	ModeNone = "none"
+	ModeWarn = "warn"
+	ModeFail = "fail"
+)
+
+// Rule is a permission a controller needs.
+type Rule struct {
+	Group       string
+	Resource    string
+	Subresource string
+	Verbs       []string
+	// Namespace of a namespaced permission, e.g. the Leases of the leader
+	// election, "" for every namespace.
+	Namespace string
+}
+
+func (r Rule) resource() string {
+	resource := r.Resource
+	if r.Subresource != "" {
+		resource += "/" + r.Subresource
+	}
+	if r.Group != "" {
+		resource += "." + r.Group
+	}
+	return resource
+}
+
+// Options are the flags that change the permissions of the controllers.
+type Options struct {
+	// LeaderElectionNamespace is the namespace of the Leases, "" when the
+	// leader election is disabled.
+	LeaderElectionNamespace string
+	// CapacityNamespace is the namespace of the CSIStorageCapacity objects,
+	// "" when the provisioner doesn't publish capacity.
+	CapacityNamespace string
+	// CapacityOwnerrefLevel is the number of owners the provisioner walks up
+	// from its pod to the owner of the CSIStorageCapacity objects, see
+	// --provisioner-capacity-ownerref-level.
+	CapacityOwnerrefLevel int
+}
+
+// Rules returns the permissions a controller needs, like the RBAC rules
+// deployed with the sidecar.
+func Rules(controller string, opts Options) []Rule {
+	var rules []Rule
+	switch controller {
+	case "attacher":
+		rules = []Rule{
+			{Resource: "persistentvolumes", Verbs: []string{"get", "list", "watch", "patch"}},
+			{Group: "storage.k8s.io", Resource: "csinodes", Verbs: []string{"get", "list", "watch"}},
+			{Group: "storage.k8s.io", Resource: "volumeattachments", Verbs: []string{"get", "list", "watch", "patch"}},
+			{Group: "storage.k8s.io", Resource: "volumeattachments", Subresource: "status", Verbs: []string{"patch"}},
+		}
+	case "provisioner":
+		rules = []Rule{
+			{Resource: "persistentvolumes", Verbs: []string{"get", "list", "watch", "create", "patch", "delete"}},
+			{Resource: "persistentvolumeclaims", Verbs: []string{"get", "list", "watch", "update"}},
+			{Group: "storage.k8s.io", Resource: "storageclasses", Verbs: []string{"get", "list", "watch"}},
+			{Resource: "events", Verbs: []string{"list", "watch", "create", "update", "patch"}},
+			{Group: "snapshot.storage.k8s.io", Resource: "volumesnapshots", Verbs: []string{"get", "list"}},
+			{Group: "snapshot.storage.k8s.io", Resource: "volumesnapshotcontents", Verbs: []string{"get", "list"}},
+			{Group: "storage.k8s.io", Resource: "csinodes", Verbs: []string{"get", "list", "watch"}},
+			{Resource: "nodes", Verbs: []string{"get", "list", "watch"}},
+			{Group: "storage.k8s.io", Resource: "volumeattachments", Verbs: []string{"get", "list", "watch"}},
+			{Group: "storage.k8s.io", Resource: "volumeattributesclasses", Verbs: []string{"get", "list", "watch"}},
+		}
+		if opts.CapacityNamespace != "" {
+			rules = append(rules, Rule{Group: "storage.k8s.io", Resource: "csistoragecapacities", Verbs: []string{"get", "list", "watch", "create", "update", "patch", "delete"}, Namespace: opts.CapacityNamespace})
+			// The owner of the CSIStorageCapacity objects is looked up
+			// from the pod, through its ReplicaSet or StatefulSet.
+			if opts.CapacityOwnerrefLevel >= 0 {
+				rules = append(rules, Rule{Resource: "pods", Verbs: []string{"get"}, Namespace: opts.CapacityNamespace})
+			}
+			if opts.CapacityOwnerrefLevel >= 1 {
+				rules = append(rules,
+					Rule{Group: "apps", Resource: "replicasets", Verbs: []string{"get"}, Namespace: opts.CapacityNamespace},
+					Rule{Group: "apps", Resource: "statefulsets", Verbs: []string{"get"}, Namespace: opts.CapacityNamespace},
+				)
+			}
+		}
+	case "resizer":
+		rules = []Rule{
+			{Resource: "persistentvolumes", Verbs: []string{"get", "list", "watch", "patch"}},
+			{Resource: "persistentvolumeclaims", Verbs: []string{"get", "list", "watch"}},
+			{Resource: "persistentvolumeclaims", Subresource: "status", Verbs: []string{"patch"}},
+			{Resource: "pods", Verbs: []string{"list", "watch"}},
+			{Resource: "events", Verbs: []string{"list", "watch", "create", "update", "patch"}},
+			{Group: "storage.k8s.io", Resource: "volumeattributesclasses", Verbs: []string{"get", "list", "watch"}},
+		}
+	}
+	if opts.LeaderElectionNamespace != "" {
+		rules = append(rules, Rule{Group: "coordination.k8s.io", Resource: "leases", Verbs: []string{"get", "watch", "list", "delete", "update", "create"}, Namespace: opts.LeaderElectionNamespace})
+	}
+	return rules
+}
+
+// Result is whether a controller has a permission.
+type Result struct {
+	Controller string
+	Rule       Rule
+	Verb       string
+	Allowed    bool
+	// Reason is why the permission is denied, or the error of the check.
+	Reason string
+	Err    error
+}
+
+// Check asks the API server with a SelfSubjectAccessReview for every verb of
+// the rules whether the client of a controller has the permission.
+func Check(ctx context.Context, client kubernetes.Interface, controller string, rules []Rule) []Result {
+	var results []Result
+	for _, rule := range rules {
+		for _, verb := range rule.Verbs {
+			review := &authorizationv1.SelfSubjectAccessReview{
+				Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+					ResourceAttributes: &authorizationv1.ResourceAttributes{
+						Namespace:   rule.Namespace,
+						Verb:        verb,
+						Group:       rule.Group,
+						Resource:    rule.Resource,
+						Subresource: rule.Subresource,
+					},
+				},
+			}
+			result := Result{Controller: controller, Rule: rule, Verb: verb}
+			response, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+			if err != nil {
+				result.Err = err
+				result.Reason = err.Error()
+			} else {
+				result.Allowed = response.Status.Allowed
+				result.Reason = response.Status.Reason
+			}
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// Report logs the permissions the controllers are missing, and the checks
+// that failed. It returns an error when a permission is missing or couldn't
+// be checked.
+func Report(results []Result) error {
+	var missing, unchecked []string
+	for _, result := range results {
+		namespace := result.Rule.Namespace
+		if namespace == "" {
+			namespace = "*"
+		}
+		permission := fmt.Sprintf("%s: %s %s in %s", result.Controller, result.Verb, result.Rule.resource(), namespace)
+		switch {
+		case result.Err != nil:
+			klog.ErrorS(result.Err, "Failed to check a permission", "controller", result.Controller, "verb", result.Verb, "resource", result.Rule.resource(), "namespace", namespace)
+			unchecked = append(unchecked, permission)
+		case !result.Allowed:
+			klog.ErrorS(nil, "Missing permission", "controller", result.Controller, "verb", result.Verb, "resource", result.Rule.resource(), "namespace", namespace, "reason", result.Reason)
+			missing = append(missing, permission)
+		}
+	}
+	var errs []error
+	if len(missing) > 0 {
+		errs = append(errs, fmt.Errorf("%d of %d permissions are missing, check the RBAC rules of the controllers:\n%s", len(missing), len(results), strings.Join(missing, "\n")))
+	}
+	if len(unchecked) > 0 {
+		errs = append(errs, fmt.Errorf("%d of %d permissions couldn't be checked:\n%s", len(unchecked), len(results), strings.Join(unchecked, "\n")))
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	klog.InfoS("Checked the permissions of the controllers", "permissions", len(results))
+	return nil
+}
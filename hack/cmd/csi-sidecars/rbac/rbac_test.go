@@ -0,0 +1,162 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rbac
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// upstreamPermissions returns the "verb resource" permissions granted by the
+// RBAC rules deployed with a sidecar, as synced into pkg/<sidecar>.
+func upstreamPermissions(t *testing.T, sidecar string) map[string]bool {
+	t.Helper()
+	path := filepath.Join("..", "..", "..", "pkg", sidecar, "deploy", "kubernetes", "rbac.yaml")
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		t.Skipf("%s doesn't exist, run hack/do_sync.sh", path)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	permissions := map[string]bool{}
+	decoder := utilyaml.NewYAMLOrJSONDecoder(f, 4096)
+	for {
+		var obj struct {
+			Kind  string              `json:"kind"`
+			Rules []rbacv1.PolicyRule `json:"rules"`
+		}
+		if err := decoder.Decode(&obj); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			t.Fatalf("failed to decode %s: %v", path, err)
+		}
+		if obj.Kind != "ClusterRole" && obj.Kind != "Role" {
+			continue
+		}
+		for _, rule := range obj.Rules {
+			for _, group := range rule.APIGroups {
+				for _, resource := range rule.Resources {
+					for _, verb := range rule.Verbs {
+						// The subresource is part of the resource in the
+						// RBAC rules.
+						r := Rule{Group: group, Resource: resource}
+						permissions[verb+" "+r.resource()] = true
+					}
+				}
+			}
+		}
+	}
+	return permissions
+}
+
+// TestRulesMatchUpstream checks that the permissions checked for a
+// controller with every optional feature enabled include all the
+// permissions the RBAC rules of its sidecar grant, so that a rule added
+// upstream isn't missed by the sync.
+func TestRulesMatchUpstream(t *testing.T) {
+	opts := Options{
+		LeaderElectionNamespace: "default",
+		CapacityNamespace:       "default",
+		CapacityOwnerrefLevel:   1,
+	}
+	for _, controller := range []string{"attacher", "provisioner", "resizer"} {
+		t.Run(controller, func(t *testing.T) {
+			upstream := upstreamPermissions(t, controller)
+
+			checked := map[string]bool{}
+			for _, rule := range Rules(controller, opts) {
+				for _, verb := range rule.Verbs {
+					checked[verb+" "+rule.resource()] = true
+				}
+			}
+			for permission := range upstream {
+				if !checked[permission] {
+					t.Errorf("the RBAC rules of the %s grant %q, which Rules doesn't check", controller, permission)
+				}
+			}
+		})
+	}
+}
+
+func TestCapacityOwnerrefLevel(t *testing.T) {
+	tests := []struct {
+		level    int
+		expected []string
+	}{
+		{level: -1},
+		{level: 0, expected: []string{"pods"}},
+		{level: 1, expected: []string{"pods", "replicasets.apps", "statefulsets.apps"}},
+		{level: 2, expected: []string{"pods", "replicasets.apps", "statefulsets.apps"}},
+	}
+	for _, test := range tests {
+		rules := Rules("provisioner", Options{CapacityNamespace: "default", CapacityOwnerrefLevel: test.level})
+		var owners []string
+		for _, rule := range rules {
+			if rule.Namespace == "default" && rule.Resource != "csistoragecapacities" {
+				owners = append(owners, rule.resource())
+			}
+		}
+		if !slices.Equal(owners, test.expected) {
+			t.Errorf("level %d: expected the permissions to get %v, got %v", test.level, test.expected, owners)
+		}
+	}
+}
+
+func TestReport(t *testing.T) {
+	leases := Rule{Group: "coordination.k8s.io", Resource: "leases", Namespace: "default"}
+	tests := []struct {
+		name          string
+		results       []Result
+		expectedError bool
+	}{
+		{
+			name:    "allowed",
+			results: []Result{{Controller: "attacher", Rule: leases, Verb: "get", Allowed: true}},
+		},
+		{
+			name:          "missing",
+			results:       []Result{{Controller: "attacher", Rule: leases, Verb: "get", Reason: "forbidden"}},
+			expectedError: true,
+		},
+		{
+			name: "unchecked",
+			results: []Result{
+				{Controller: "attacher", Rule: leases, Verb: "get", Allowed: true},
+				{Controller: "attacher", Rule: leases, Verb: "update", Err: errors.New("connection refused")},
+			},
+			expectedError: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := Report(test.results)
+			if (err != nil) != test.expectedError {
+				t.Errorf("expected an error: %t, got %v", test.expectedError, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,316 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	flag "github.com/spf13/pflag"
+	"k8s.io/component-base/logs"
+	"k8s.io/klog/v2"
+
+	"github.com/kubernetes-csi/csi-sidecars/cmd/csi-sidecars/config"
+	"github.com/kubernetes-csi/csi-sidecars/cmd/csi-sidecars/logging"
+	"github.com/kubernetes-csi/csi-sidecars/pkg/admin"
+	"github.com/kubernetes-csi/csi-sidecars/pkg/operations"
+	"github.com/kubernetes-csi/csi-sidecars/pkg/queues"
+)
+
+// reloader applies the changes of the --config file while the sidecars run.
+// Only the flags in apply are changed, the other changes need a restart and
+// are logged and ignored. Flags set on the command line or by environment
+// variables keep their value.
+type reloader struct {
+	flags      *flag.FlagSet
+	path       string
+	overridden map[string]bool
+	// fromFile are the flags the file sets, they go back to their default
+	// when they're removed from the file.
+	fromFile map[string]bool
+	// apply maps the flags that can change at runtime to the applier that
+	// applies them.
+	apply map[string]string
+	// appliers apply the values of their flags once they're all set, e.g.
+	// both retry intervals. They return an error when the values can't be
+	// applied and the flags are restored.
+	appliers map[string]func() error
+	data     []byte
+
+	workerThreads int
+	timeout       time.Duration
+}
+
+// newReloader returns the reloader of the --config file, values are the ones
+// the file had at startup. The worker count and the timeout of the sidecars
+// can only be lowered: the sidecars start their workers once and set their
+// own timeouts.
+func newReloader(flags *flag.FlagSet, path string, overridden map[string]bool, values map[string]string, standalone string) *reloader {
+	r := &reloader{
+		flags:         flags,
+		path:          path,
+		overridden:    overridden,
+		fromFile:      map[string]bool{},
+		workerThreads: config.Configuration.AttacherConfiguration.WorkerThreads,
+		timeout:       config.Configuration.AttacherConfiguration.Timeout,
+	}
+	r.data, _ = os.ReadFile(path)
+	for name := range values {
+		r.fromFile[name] = true
+	}
+
+	r.appliers = map[string]func() error{
+		"kube-api-rate-limit": applyKubeAPIRateLimit,
+		"retry-intervals":     applyRetryIntervals,
+		"worker-threads":      r.applyWorkerThreads,
+		"timeout":             r.applyTimeout,
+		"v":                   r.applyVerbosity,
+	}
+	r.apply = map[string]string{
+		"kube-api-qps":                             "kube-api-rate-limit",
+		"kube-api-burst":                           "kube-api-rate-limit",
+		"kube-api-max-qps":                         "kube-api-rate-limit",
+		"kube-api-max-burst":                       "kube-api-rate-limit",
+		"retry-interval-start":                     "retry-intervals",
+		"retry-interval-max":                       "retry-intervals",
+		attacherFlag(standalone, "worker-threads"): "worker-threads",
+		attacherFlag(standalone, "timeout"):        "timeout",
+		"v":                                        "v",
+	}
+	for _, controller := range config.Controllers {
+		r.appliers[controller+"-v"] = func() error {
+			logging.SetVerbosity(controller, *config.Configuration.Verbosity[controller])
+			return nil
+		}
+		r.apply[controller+"-v"] = controller + "-v"
+		r.apply[controller+"-kube-api-qps"] = "kube-api-rate-limit"
+		r.apply[controller+"-kube-api-burst"] = "kube-api-rate-limit"
+	}
+	return r
+}
+
+// run reloads the file when it changes or on SIGHUP until ctx is done.
+func (r *reloader) run(ctx context.Context) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var events <-chan fsnotify.Event
+	var watchErrors <-chan error
+	watcher, err := fsnotify.NewWatcher()
+	if err == nil {
+		defer watcher.Close()
+		// Watch the directory, the file of a ConfigMap volume is replaced
+		// through a symlink.
+		err = watcher.Add(filepath.Dir(r.path))
+		events, watchErrors = watcher.Events, watcher.Errors
+	}
+	if err != nil {
+		klog.ErrorS(err, "Failed to watch the configuration file, it's only reloaded on SIGHUP", "path", r.path)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			klog.InfoS("Reloading the configuration on SIGHUP", "path", r.path)
+			r.reload()
+		case <-events:
+			data, err := os.ReadFile(r.path)
+			if err != nil || bytes.Equal(data, r.data) {
+				continue
+			}
+			klog.InfoS("Reloading the changed configuration", "path", r.path)
+			r.reload()
+		case err := <-watchErrors:
+			klog.ErrorS(err, "Failed to watch the configuration file", "path", r.path)
+		}
+	}
+}
+
+func (r *reloader) reload() {
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		klog.ErrorS(err, "Failed to reload the configuration", "path", r.path)
+		return
+	}
+	r.data = data
+	values, err := config.ReadFile(r.path)
+	if err != nil {
+		klog.ErrorS(err, "Failed to reload the configuration", "path", r.path)
+		return
+	}
+
+	names := make([]string, 0, len(values)+len(r.fromFile))
+	for name := range values {
+		names = append(names, name)
+	}
+	for name := range r.fromFile {
+		if _, ok := values[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	// Every changed flag is set before the appliers run, so that the flags
+	// an applier checks together, e.g. both retry intervals, are checked
+	// with their new values.
+	type change struct {
+		name, previous, value string
+	}
+	var appliers []string
+	changes := map[string][]change{}
+	for _, name := range names {
+		f := r.flags.Lookup(name)
+		if f == nil {
+			klog.ErrorS(nil, "Ignoring an unknown flag in the configuration", "flag", name, "path", r.path)
+			continue
+		}
+		if r.overridden[name] {
+			continue
+		}
+		value, ok := values[name]
+		if !ok {
+			value = f.DefValue
+		}
+		if sameValue(f, value) {
+			continue
+		}
+		applier, ok := r.apply[name]
+		if !ok {
+			klog.ErrorS(nil, "Ignoring the changed flag, the change needs a restart", "flag", name, "value", f.Value.String(), "newValue", value)
+			continue
+		}
+		previous := f.Value.String()
+		if err := r.flags.Set(name, value); err != nil {
+			klog.ErrorS(err, "Ignoring the invalid flag", "flag", name, "newValue", value)
+			continue
+		}
+		if _, ok := changes[applier]; !ok {
+			appliers = append(appliers, applier)
+		}
+		changes[applier] = append(changes[applier], change{name: name, previous: previous, value: value})
+	}
+
+	// The flags of an applier that fails are restored together.
+	for _, applier := range appliers {
+		apply := r.appliers[applier]
+		if err := apply(); err != nil {
+			for _, c := range changes[applier] {
+				klog.ErrorS(err, "Ignoring the changed flag", "flag", c.name, "value", c.previous, "newValue", c.value)
+				_ = r.flags.Set(c.name, c.previous)
+			}
+			_ = apply()
+			continue
+		}
+		for _, c := range changes[applier] {
+			if _, ok := values[c.name]; ok {
+				config.SetSource(c.name, config.SourceFile)
+			} else {
+				config.SetSource(c.name, config.SourceDefault)
+			}
+			klog.InfoS("Reloaded flag", "flag", c.name, "previous", c.previous, "value", r.flags.Lookup(c.name).Value.String())
+		}
+	}
+
+	r.fromFile = map[string]bool{}
+	for name := range values {
+		r.fromFile[name] = true
+	}
+}
+
+// sameValue compares the value of f to value, e.g. the durations 1m0s and
+// 60s are the same.
+func sameValue(f *flag.Flag, value string) bool {
+	current := f.Value.String()
+	if current == value {
+		return true
+	}
+	switch f.Value.Type() {
+	case "duration":
+		a, errA := time.ParseDuration(current)
+		b, errB := time.ParseDuration(value)
+		return errA == nil && errB == nil && a == b
+	case "bool":
+		a, errA := strconv.ParseBool(current)
+		b, errB := strconv.ParseBool(value)
+		return errA == nil && errB == nil && a == b
+	case "int", "int32", "int64", "uint", "uint32", "uint64", "float32", "float64":
+		a, errA := strconv.ParseFloat(current, 64)
+		b, errB := strconv.ParseFloat(value, 64)
+		return errA == nil && errB == nil && a == b
+	}
+	return false
+}
+
+func applyKubeAPIRateLimit() error {
+	setKubeAPIRateLimits()
+	return nil
+}
+
+func applyRetryIntervals() error {
+	start, maxDelay := config.Configuration.RetryIntervalStart, config.Configuration.RetryIntervalMax
+	if start <= 0 || start > maxDelay {
+		return fmt.Errorf("--retry-interval-start=%s must be positive and at most --retry-interval-max=%s", start, maxDelay)
+	}
+	queues.SetBackoff("", start, maxDelay)
+	return nil
+}
+
+func (r *reloader) applyWorkerThreads() error {
+	workers := config.Configuration.AttacherConfiguration.WorkerThreads
+	if workers <= 0 || workers > r.workerThreads {
+		return fmt.Errorf("the sidecars started %d workers, raising their number needs a restart", r.workerThreads)
+	}
+	limit := workers
+	if workers == r.workerThreads {
+		limit = 0
+	}
+	for _, controller := range config.Controllers {
+		admin.SetWorkerLimit(controller, limit)
+	}
+	return nil
+}
+
+func (r *reloader) applyTimeout() error {
+	timeout := config.Configuration.AttacherConfiguration.Timeout
+	if timeout <= 0 || timeout > r.timeout {
+		return fmt.Errorf("the sidecars started with a timeout of %s, raising it needs a restart", r.timeout)
+	}
+	if timeout == r.timeout {
+		timeout = 0
+	}
+	operations.SetRPCTimeout(timeout)
+	return nil
+}
+
+// applyVerbosity changes -v, the JSON logging format keeps the level it
+// started with.
+func (r *reloader) applyVerbosity() error {
+	_, err := logs.GlogSetter(r.flags.Lookup("v").Value.String())
+	return err
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kubernetes-csi/csi-sidecars/cmd/csi-sidecars/config"
+	flag "github.com/spf13/pflag"
+)
+
+// TestReloadRetryIntervals reloads both retry intervals at once, they're
+// only checked together once both are set.
+func TestReloadRetryIntervals(t *testing.T) {
+	tests := []struct {
+		name          string
+		file          string
+		expectedStart time.Duration
+		expectedMax   time.Duration
+	}{
+		{
+			name:          "lower both",
+			file:          "retry-interval-start: 10s\nretry-interval-max: 1m\n",
+			expectedStart: 10 * time.Second,
+			expectedMax:   time.Minute,
+		},
+		{
+			name:          "raise both",
+			file:          "retry-interval-start: 10m\nretry-interval-max: 20m\n",
+			expectedStart: 10 * time.Minute,
+			expectedMax:   20 * time.Minute,
+		},
+		{
+			name:          "invalid pair is restored together",
+			file:          "retry-interval-start: 2m\nretry-interval-max: 1m\n",
+			expectedStart: 2 * time.Minute,
+			expectedMax:   5 * time.Minute,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte("retry-interval-start: 2m\nretry-interval-max: 5m\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			flags := flag.NewFlagSet("csi-sidecars", flag.ContinueOnError)
+			flags.DurationVar(&config.Configuration.RetryIntervalStart, "retry-interval-start", time.Second, "")
+			flags.DurationVar(&config.Configuration.RetryIntervalMax, "retry-interval-max", 5*time.Minute, "")
+			values, err := config.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, value := range values {
+				if err := flags.Set(name, value); err != nil {
+					t.Fatal(err)
+				}
+			}
+			r := newReloader(flags, path, map[string]bool{}, values, "")
+
+			if err := os.WriteFile(path, []byte(test.file), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			r.reload()
+
+			if start, maxDelay := config.Configuration.RetryIntervalStart, config.Configuration.RetryIntervalMax; start != test.expectedStart || maxDelay != test.expectedMax {
+				t.Errorf("expected the retry intervals %s and %s, got %s and %s", test.expectedStart, test.expectedMax, start, maxDelay)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/klog/v2"
+
+	"github.com/kubernetes-csi/csi-lib-utils/standardflags"
+	"github.com/kubernetes-csi/csi-sidecars/cmd/csi-sidecars/config"
+	"github.com/kubernetes-csi/csi-sidecars/pkg/operations"
+	"github.com/kubernetes-csi/csi-sidecars/pkg/tracing"
+)
+
+// customizeRestConfig is called by every sidecar right after it sets the QPS
+// and burst of its Kubernetes client config, do_sync.sh inserts the call.
+// It's the place for settings that the AIO binary applies to every sidecar.
+func customizeRestConfig(controller string, restConfig *rest.Config) {
+	// The API server audit logs and API Priority and Fairness tell the
+	// controllers apart by their user agent.
+	restConfig.UserAgent = controllerUserAgent(controller)
+	useControllerIdentity(controller, restConfig)
+	rateLimitKubeAPI(controller, restConfig)
+	restConfig.Wrap(operations.AnnotateEvents)
+	if config.Configuration.Tracing.Exporter != tracing.ExporterNone {
+		restConfig.Wrap(tracing.WrapTransport(controller))
+	}
+}
+
+// controllerKubeConfigs are the client configs of the controllers with
+// their own kubeconfig, see loadControllerKubeConfigs.
+var controllerKubeConfigs = map[string]*rest.Config{}
+
+// loadControllerKubeConfigs loads --<controller>-kubeconfig of every enabled
+// controller, so that an invalid kubeconfig fails at startup.
+func loadControllerKubeConfigs(controllers []string) error {
+	for _, controller := range controllers {
+		path := *config.Configuration.KubeConfig[controller]
+		if path == "" {
+			continue
+		}
+		restConfig, err := clientcmd.BuildConfigFromFlags(config.Configuration.Master, path)
+		if err != nil {
+			return fmt.Errorf("failed to load --%s-kubeconfig: %w", controller, err)
+		}
+		controllerKubeConfigs[controller] = restConfig
+	}
+	return nil
+}
+
+// useControllerIdentity makes the clients of a controller act with its own
+// identity, see --<controller>-kubeconfig and
+// --<controller>-impersonate-service-account. The settings of the sidecar,
+// like the QPS and the content type, are kept.
+func useControllerIdentity(controller string, restConfig *rest.Config) {
+	if identity, ok := controllerKubeConfigs[controller]; ok {
+		settings := rest.CopyConfig(restConfig)
+		*restConfig = *rest.CopyConfig(identity)
+		restConfig.QPS = settings.QPS
+		restConfig.Burst = settings.Burst
+		restConfig.ContentType = settings.ContentType
+		restConfig.AcceptContentTypes = settings.AcceptContentTypes
+		restConfig.UserAgent = settings.UserAgent
+		restConfig.Timeout = settings.Timeout
+	}
+	if serviceAccount := *config.Configuration.ImpersonateServiceAccount[controller]; serviceAccount != "" {
+		namespace, name, _ := strings.Cut(serviceAccount, "/")
+		// The API server adds the groups of the ServiceAccount.
+		restConfig.Impersonate = rest.ImpersonationConfig{
+			UserName: fmt.Sprintf("system:serviceaccount:%s:%s", namespace, name),
+		}
+	}
+}
+
+// controllerUserAgent returns the user agent of the clients of a controller,
+// like the default user agent of client-go with the controller as comment,
+// e.g. csi-sidecars/v1.0.0 (linux/amd64) attacher.
+func controllerUserAgent(controller string) string {
+	return fmt.Sprintf("csi-sidecars/%s (%s/%s) %s", version, runtime.GOOS, runtime.GOARCH, controller)
+}
+
+// Like client-go, the requests that waited this long for the client-side
+// rate limiter are logged at level 3, and at level 0 past the extra long
+// latency.
+const (
+	longThrottleLatency      = time.Second
+	extraLongThrottleLatency = 10 * time.Second
+)
+
+// kubeAPILimiter is a client-go token bucket rate limiter whose QPS and
+// burst can change while the sidecars run, see setKubeAPIRateLimits.
+type kubeAPILimiter struct {
+	mu      sync.RWMutex
+	qps     float32
+	burst   int
+	limiter flowcontrol.RateLimiter
+}
+
+// set replaces the token bucket when the QPS or burst changed, the requests
+// waiting for the previous one keep waiting for it. A negative QPS disables
+// the limit.
+func (l *kubeAPILimiter) set(qps float32, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.limiter != nil && l.qps == qps && l.burst == burst {
+		return
+	}
+	l.qps, l.burst = qps, burst
+	if qps < 0 {
+		l.limiter = flowcontrol.NewFakeAlwaysRateLimiter()
+	} else {
+		l.limiter = flowcontrol.NewTokenBucketRateLimiter(qps, burst)
+	}
+}
+
+// Wait waits for a token of the current token bucket.
+func (l *kubeAPILimiter) Wait(ctx context.Context) error {
+	l.mu.RLock()
+	limiter := l.limiter
+	l.mu.RUnlock()
+	return limiter.Wait(ctx)
+}
+
+var (
+	kubeAPILimitersMu sync.Mutex
+	// kubeAPILimiters are the limiters of every controller, the one of ""
+	// is the ceiling of all of them, see setKubeAPIRateLimits.
+	kubeAPILimiters = map[string]*kubeAPILimiter{}
+)
+
+// kubeAPILimiterOf returns the limiter of a controller, or of the ceiling
+// of every controller for "". kubeAPILimitersMu must be held.
+func kubeAPILimiterOf(controller string) *kubeAPILimiter {
+	limiter, ok := kubeAPILimiters[controller]
+	if !ok {
+		limiter = &kubeAPILimiter{}
+		limiter.set(kubeAPIRateLimit(controller))
+		kubeAPILimiters[controller] = limiter
+	}
+	return limiter
+}
+
+// rateLimitKubeAPI replaces the client-side rate limiter of the clients
+// built from restConfig with the limiter of the controller, shared by all
+// its clients, and the ceiling of every controller. setKubeAPIRateLimits
+// changes them while the sidecars run. Clients built from a copy of
+// restConfig with another QPS, like the capacity client of the provisioner,
+// are limited by both. The leader election isn't limited, a busy controller
+// must keep renewing its Lease, which is why the limiters wrap the transport
+// instead of being set as the RateLimiter of restConfig.
+func rateLimitKubeAPI(controller string, restConfig *rest.Config) {
+	restConfig.QPS = -1
+	kubeAPILimitersMu.Lock()
+	limiters := []*kubeAPILimiter{kubeAPILimiterOf(controller), kubeAPILimiterOf("")}
+	kubeAPILimitersMu.Unlock()
+	restConfig.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &rateLimitedRoundTripper{rt: rt, controller: controller, limiters: limiters}
+	})
+}
+
+// setKubeAPIRateLimits sets the QPS and burst of the clients of every
+// controller and their ceiling, e.g. --attacher-kube-api-qps, --kube-api-qps
+// and --kube-api-max-qps.
+func setKubeAPIRateLimits() {
+	kubeAPILimitersMu.Lock()
+	defer kubeAPILimitersMu.Unlock()
+	kubeAPILimiterOf("")
+	for controller, limiter := range kubeAPILimiters {
+		limiter.set(kubeAPIRateLimit(controller))
+	}
+}
+
+// kubeAPIRateLimit returns the QPS and burst of the clients of a controller,
+// or of the ceiling of every controller for "". A negative QPS disables the
+// limit.
+func kubeAPIRateLimit(controller string) (float32, int) {
+	if controller == "" {
+		// Without a ceiling, every controller only has its own limit.
+		if config.Configuration.KubeAPIMaxQPS <= 0 {
+			return -1, 0
+		}
+		burst := config.Configuration.KubeAPIMaxBurst
+		if burst <= 0 {
+			burst = max(int(config.Configuration.KubeAPIMaxQPS), 1)
+		}
+		return float32(config.Configuration.KubeAPIMaxQPS), burst
+	}
+
+	qps, burst := standardflags.Configuration.KubeAPIQPS, standardflags.Configuration.KubeAPIBurst
+	if v, ok := config.Configuration.KubeAPIQPS[controller]; ok && *v != 0 {
+		qps = *v
+	}
+	if v, ok := config.Configuration.KubeAPIBurst[controller]; ok && *v != 0 {
+		burst = *v
+	}
+	// Like client-go, 0 uses the defaults and a negative QPS disables the limit.
+	if qps == 0 {
+		qps = float64(rest.DefaultQPS)
+	}
+	if burst <= 0 {
+		burst = rest.DefaultBurst
+	}
+	return float32(qps), burst
+}
+
+type rateLimitedRoundTripper struct {
+	rt         http.RoundTripper
+	controller string
+	limiters   []*kubeAPILimiter
+}
+
+func (rt *rateLimitedRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	if !strings.HasPrefix(r.URL.Path, "/apis/coordination.k8s.io/") {
+		start := time.Now()
+		for _, limiter := range rt.limiters {
+			if err := limiter.Wait(r.Context()); err != nil {
+				return nil, err
+			}
+		}
+		// Tell the client-side throttling apart from API Priority and
+		// Fairness, like client-go does.
+		if latency := time.Since(start); latency > longThrottleLatency {
+			level := 3
+			if latency > extraLongThrottleLatency {
+				level = 0
+			}
+			klog.FromContext(r.Context()).V(level).Info("Waited due to client-side throttling, not priority and fairness", "controller", rt.controller, "latency", latency, "verb", r.Method, "url", r.URL.String())
+		}
+	}
+	return rt.rt.RoundTrip(r)
+}
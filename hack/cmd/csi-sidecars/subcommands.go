@@ -0,0 +1,90 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	goflag "flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/kubernetes-csi/csi-sidecars/cmd/csi-sidecars/config"
+	sidecarsctl "github.com/kubernetes-csi/csi-sidecars/cmd/csi-sidecars/ctl"
+	"github.com/kubernetes-csi/csi-sidecars/cmd/csi-sidecars/migrate"
+	flag "github.com/spf13/pflag"
+)
+
+// subcommands run instead of the sidecars when the first argument names
+// them, e.g. `csi-sidecars ctl controllers`. They get the arguments after
+// their name and return the exit code.
+var subcommands = map[string]func(args []string) int{
+	"ctl":     sidecarsctl.Run,
+	"migrate": runMigrate,
+	"probe":   runProbe,
+}
+
+// runSubcommand runs the subcommand named by the first argument and exits,
+// it returns when there's none.
+func runSubcommand() {
+	if len(os.Args) < 2 {
+		return
+	}
+	run, ok := subcommands[os.Args[1]]
+	if !ok {
+		return
+	}
+	os.Exit(run(os.Args[2:]))
+}
+
+// runMigrate runs `csi-sidecars migrate` with the flags of the AIO binary,
+// the flags of the sidecars it has under the same name are kept as they
+// are.
+func runMigrate(args []string) int {
+	flags := flag.NewFlagSet("csi-sidecars", flag.ContinueOnError)
+	registerFlags(goflag.NewFlagSet("csi-sidecars", goflag.ContinueOnError), flags, "")
+	return migrate.Run(args, flags)
+}
+
+// standaloneController returns the sidecar the binary replaces when it's
+// invoked as csi-attacher, csi-provisioner or csi-resizer, e.g. through a
+// symlink, or as `csi-sidecars attacher`. The binary then runs only that
+// sidecar and accepts its unprefixed flags, so that the standalone images
+// can be replaced without changing their manifests. The subcommand is
+// removed from os.Args.
+func standaloneController() string {
+	if name, ok := strings.CutPrefix(filepath.Base(os.Args[0]), "csi-"); ok && slices.Contains(config.Controllers, name) {
+		return name
+	}
+	if len(os.Args) > 1 && slices.Contains(config.Controllers, os.Args[1]) {
+		name := os.Args[1]
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+		return name
+	}
+	return ""
+}
+
+// addMissingFlags adds the flags of from that to doesn't define yet. The
+// standalone sidecars define some flags the AIO binary already has, e.g.
+// --retry-interval-start, the sidecars read the AIO ones.
+func addMissingFlags(to, from *goflag.FlagSet) {
+	from.VisitAll(func(f *goflag.Flag) {
+		if to.Lookup(f.Name) == nil {
+			to.Var(f.Value, f.Name, f.Usage)
+		}
+	})
+}
@@ -45,6 +45,200 @@ symlink_from_root_to_hack() {
   ln -s $PWD/$file $PWD/$file_without_hack
 }
 
+# add_operation_tracking starts an operation for every item the controllers of a
+# sidecar dequeue, it ends when the item is marked as done. The operation ID is
+# added to the logs, the CSI RPCs and the Events of the item. Sync functions that
+# receive a context pass the operation down to the CSI calls, the others only
+# record it. While the processing of the item is paused through the admin API, or
+# while the controller has no worker left after a reload lowered its worker
+# count, the sync function returns right away and operations.Start requeues
+# the item.
+#
+# Usage:
+# add_operation_tracking <sidecar> <directory>
+add_operation_tracking() {
+  sidecar="$1"
+  dir="$2"
+  for FILE in $(grep -rlE --include='*.go' --exclude='*_test.go' '^\s+defer ctrl\.\w+\.Done\(key\)$' "${dir}"); do
+    awk -v sidecar="${sidecar}" -v file="${FILE}" '
+      /^func / { has_ctx = ($0 ~ /ctx context\.Context/) }
+      # The innermost function the item is processed in, its results tell how
+      # to return early.
+      /func[ (].*\{$/ { signature = $0 }
+      { print }
+      match($0, /^\t+defer ctrl\.[A-Za-z0-9_]+\.Done\(key\)$/) {
+        indent = $0; sub(/defer.*/, "", indent)
+        queue = $0; sub(/^\t+defer ctrl\./, "", queue); sub(/\.Done\(key\)$/, "", queue)
+        results = signature; sub(/ *\{$/, "", results); sub(/^.*\)/, "", results); gsub(/ /, "", results)
+        if (signature ~ /\) *\([^()]*\) *\{$/) {
+          results = "multiple values"
+        }
+        if (results == "") {
+          ret = "return"
+        } else if (results == "bool") {
+          # processNextWorkItem style, keep processing the queue.
+          ret = "return true"
+        } else if (results == "error") {
+          ret = "return nil"
+        } else {
+          printf "%s: cannot return early from a function returning %s\n", file, results > "/dev/stderr"
+          exit 1
+        }
+        if (has_ctx && indent == "\t") {
+          printf "%sctx, endOperation, started := operations.Start(ctx, \"%s\", \"%s\", key)\n", indent, sidecar, queue
+        } else {
+          printf "%sendOperation, started := operations.Track(\"%s\", \"%s\", key)\n", indent, sidecar, queue
+        }
+        printf "%sif !started {\n%s\t%s\n%s}\n", indent, indent, ret, indent
+        printf "%sdefer endOperation()\n", indent
+      }
+    ' "${FILE}" >"${FILE}.new"
+    mv "${FILE}.new" "${FILE}"
+    grep -q '"github.com/kubernetes-csi/csi-sidecars/pkg/operations"' "${FILE}" ||
+      sed -i".bak" '0,/^import (/s//import (\n\t"github.com\/kubernetes-csi\/csi-sidecars\/pkg\/operations"/' "${FILE}"
+  done
+}
+
+# register_queues wraps the creation of the rate limiting queues of a sidecar with
+# queues.Register so that the admin API can add items to them.
+#
+# Usage:
+# register_queues <sidecar> <directory>
+register_queues() {
+  sidecar="$1"
+  dir="$2"
+  for FILE in $(grep -rlE --include='*.go' --exclude='*_test.go' 'workqueue\.NewTypedRateLimitingQueueWithConfig(\[\w+\])?\(' "${dir}"); do
+    sed -E -i".bak" \
+      -e 's/^(\s+)(\w+):(\s+)(workqueue\.NewTypedRateLimitingQueueWithConfig(\[\w+\])?\(.*\)),$/\1\2:\3queues.Register("'"${sidecar}"'", "\2", \4),/' \
+      -e 's/^(\s+)(\w+\.)?(\w+) (:?=) (workqueue\.NewTypedRateLimitingQueueWithConfig(\[\w+\])?\(.*\))$/\1\2\3 \4 queues.Register("'"${sidecar}"'", "\3", \5)/' \
+      "${FILE}"
+    if grep -q 'queues\.Register(' "${FILE}" && ! grep -q '"github.com/kubernetes-csi/csi-sidecars/pkg/queues"' "${FILE}"; then
+      sed -i".bak" '0,/^import (/s//import (\n\t"github.com\/kubernetes-csi\/csi-sidecars\/pkg\/queues"/' "${FILE}"
+    fi
+  done
+}
+
+# use_featuregates rewrites the uses of utilfeature.DefaultFeatureGate in the
+# files of a sidecar to the feature gates of the sidecar, see
+# hack/pkg/featuregates. Files that were already rewritten are left as is.
+#
+# Usage:
+# use_featuregates <sidecar> <file>...
+use_featuregates() {
+  sidecar="$1"
+  shift
+  for FILE in "$@"; do
+    if ! grep -qE 'utilfeature\.Default(Mutable)?FeatureGate' "${FILE}"; then
+      continue
+    fi
+    sed -E -i".bak" "s/utilfeature\.Default(Mutable)?FeatureGate/featuregates.For(\"${sidecar}\")/g" "${FILE}"
+    grep -q 'utilfeature\.' "${FILE}" || sed -i".bak" '/utilfeature "k8s.io\/apiserver\/pkg\/util\/feature"/d' "${FILE}"
+    sed -i".bak" '0,/^import (/s//import (\n\t"github.com\/kubernetes-csi\/csi-sidecars\/pkg\/featuregates"/' "${FILE}"
+  done
+}
+
+# use_shared_config makes the standalone entrypoint of a sidecar register its
+# flags with the config package shared with the AIO binary, the way the fork
+# in hack/pkg/attacher/cmd/csi-attacher/main.go does it for the attacher: the
+# flag definitions are commented out, Register<Type>Flags registers the flags
+# to a struct, and after the flags are parsed the vars become pointers to its
+# fields. A mapping <var>=<Field>:<type> converts the field to <type> first.
+#
+# Usage:
+# use_shared_config <sidecar> <Type> <flag set> <var>=<Field>[:<type>]...
+use_shared_config() {
+  sidecar="$1"
+  type="$2"
+  flagset="$3"
+  shift 3
+  FILE="pkg/${sidecar}/cmd/csi-${sidecar}/main.go"
+  if grep -q "Register${type}Flags" "${FILE}"; then
+    return
+  fi
+  awk -v sidecar="${sidecar}" -v type="${type}" -v flagset="${flagset}" -v mappings="$*" '
+    function printValidate() {
+      printf "\tif err := %s.Validate(); err != nil {\n", configuration
+      print "\t\tklog.Fatal(err)"
+      print "\t}"
+    }
+    # The first pass only looks for the feature gates.
+    NR == FNR {
+      if (/SetFromMap\(/) {
+        gatesSet = 1
+      }
+      next
+    }
+    BEGIN {
+      configuration = sidecar "Configuration"
+      n = split(mappings, m, " ")
+      for (i = 1; i <= n; i++) {
+        split(m[i], kv, "=")
+        names[i] = kv[1]
+        field[kv[1]] = kv[2]
+      }
+    }
+    /^import \(/ && !imported {
+      print
+      printf "\t%sconfiguration \"github.com/kubernetes-csi/csi-sidecars/pkg/%s/cmd/csi-%s/config\"\n", sidecar, sidecar, sidecar
+      imported = 1
+      next
+    }
+    /^var \(/ && !declared {
+      printf "var %s = %sconfiguration.%sConfiguration{}\n\n", configuration, sidecar, type
+      declared = 1
+    }
+    /^\t[A-Za-z0-9_]+ += flag\./ && ($1 in field) {
+      print "\t// " substr($0, 2)
+      next
+    }
+    (/^\tflag\.CommandLine\.AddGoFlagSet\(goflag\.CommandLine\)$/ || /^\tflag\.Parse\(\)$/) && !registered {
+      print "\t// override: the flags commented out above are registered to the struct shared with the AIO binary."
+      printf "\t%sconfiguration.Register%sFlags(%s, &%s)\n", sidecar, type, flagset, configuration
+      registered = 1
+    }
+    /^\tflag\.Parse\(\)$/ {
+      print
+      print ""
+      print "\t// override: the rest of this code needs pointers to the parsed flags."
+      for (i = 1; i <= n; i++) {
+        name = names[i]
+        split(field[name], ft, ":")
+        if (ft[2] != "") {
+          printf "\t%sValue := %s(%s.%s)\n", name, ft[2], configuration, ft[1]
+          printf "\t%s := &%sValue\n", name, name
+        } else {
+          printf "\t%s := &%s.%s\n", name, configuration, ft[1]
+        }
+      }
+      if (gatesSet) {
+        # Validate checks the flags that depend on the feature gates, it
+        # runs once they are set.
+        validatePending = 1
+      } else {
+        printValidate()
+      }
+      next
+    }
+    validatePending && /SetFromMap\(/ {
+      inGates = 1
+    }
+    validatePending && inGates && /^\t}$/ {
+      print
+      printValidate()
+      validatePending = 0
+      next
+    }
+    { print }
+    END {
+      if (validatePending) {
+        print "use_shared_config: the end of the feature gates block of " FILENAME " was not found" >"/dev/stderr"
+        exit 1
+      }
+    }
+  ' "${FILE}" "${FILE}" >"${FILE}.new"
+  mv "${FILE}.new" "${FILE}"
+}
+
 # loop params: [repository,branch]
 for i in attacher,master provisioner,master resizer,master; do
   IFS=',' read SIDECAR SIDECAR_HASH <<<"${i}"
@@ -90,6 +284,39 @@ for i in attacher,master provisioner,master resizer,master; do
       find . -type f -exec grep -q "github.com/kubernetes-csi/external-${SIDECAR}/" --files-with-matches {} \; -print |
         xargs sed -E -i".bak" "s%github.com/kubernetes-csi/external-${SIDECAR}/(v[0-9]+/)?%github.com/kubernetes-csi/csi-sidecars/pkg/${SIDECAR}/%g"
     )
+
+    # Record a span for every CSI RPC and propagate its trace context to the driver
+    # as gRPC metadata, spans are only exported when --tracing-exporter is set.
+    # The RPCs made for a volume are also added to its history, see /debug/volumes.
+    (
+      cd pkg/${SIDECAR}
+      { grep -rl --include='*.go' --exclude='*_test.go' "connection.OnConnectionLoss(connection.ExitOnConnectionLoss())" . || [[ $? == 1 ]]; } |
+        xargs -r sed -i".bak" \
+          -e "s/connection.OnConnectionLoss(connection.ExitOnConnectionLoss())/&, connection.WithOtelTracing(), operations.RecordRPCs(\"${SIDECAR}\")/g" \
+          -e '0,/^import (/s//import (\n\t"github.com\/kubernetes-csi\/csi-sidecars\/pkg\/operations"/'
+    )
+    # Every sidecar has its own feature gates, see --feature-gates. The
+    # entrypoints are rewritten after they're copied to cmd/csi-sidecars.
+    use_featuregates ${SIDECAR} $(grep -rlE --include='*.go' --exclude-dir=cmd 'utilfeature\.Default(Mutable)?FeatureGate' pkg/${SIDECAR} || [[ $? == 1 ]])
+    add_operation_tracking ${SIDECAR} pkg/${SIDECAR}/pkg
+    register_queues ${SIDECAR} pkg/${SIDECAR}/pkg
+
+    # The provisioner queue lives in sig-storage-lib-external-provisioner, start
+    # the operations in the csiProvisioner calls instead.
+    if [[ ${SIDECAR} == provisioner ]] && grep -q '^func (p \*csiProvisioner) Provision(ctx context.Context, options controller.ProvisionOptions)' pkg/provisioner/pkg/controller/controller.go; then
+      sed -E -i".bak" \
+        -e 's/^func \(p \*csiProvisioner\) Provision\(ctx context.Context, options controller.ProvisionOptions\).*\{$/&\n\tctx, endOperation, startErr := operations.StartForObject(ctx, "provisioner", "PersistentVolumeClaim", options.PVC.Namespace, options.PVC.Name)\n\tif startErr != nil {\n\t\treturn nil, controller.ProvisioningNoChange, startErr\n\t}\n\tdefer endOperation()/' \
+        -e 's/^func \(p \*csiProvisioner\) Delete\(ctx context.Context, volume \*v1.PersistentVolume\) error \{$/&\n\tctx, endOperation, startErr := operations.StartForObject(ctx, "provisioner", "PersistentVolume", "", volume.Name)\n\tif startErr != nil {\n\t\treturn startErr\n\t}\n\tdefer endOperation()/' \
+        -e '0,/^import \(/s//import (\n\t"github.com\/kubernetes-csi\/csi-sidecars\/pkg\/operations"/' \
+        pkg/provisioner/pkg/controller/controller.go
+    fi
+
+    # The standalone entrypoints are rewritten in place after they're copied to
+    # cmd/csi-sidecars, e.g. by use_shared_config. Keep them as cloned so that
+    # the copies made when the script runs again don't get the rewrites.
+    for FILE in pkg/${SIDECAR}/cmd/csi-${SIDECAR}/*.go; do
+      cp -- "${FILE}" "${FILE}.upstream"
+    done
   fi
 
   # After cloning a CSI repository its entrypoints have additional code that now belong
@@ -100,7 +327,11 @@ for i in attacher,master provisioner,master resizer,master; do
   # may have code that
   for FILE in pkg/${SIDECAR}/cmd/csi-${SIDECAR}/*.go; do
     NEW_FILE="cmd/csi-sidecars/${SIDECAR}_$(basename ${FILE})"
-    cp -v -- "${FILE}" "${NEW_FILE}"
+    if [[ -f "${FILE}.upstream" ]]; then
+      cp -v -- "${FILE}.upstream" "${NEW_FILE}"
+    else
+      cp -v -- "${FILE}" "${NEW_FILE}"
+    fi
     # Rename main()
     sed -i".bak" "s/func main()/func ${SIDECAR}_main(ctx context.Context)/g" "${NEW_FILE}"
     # Remove variables (mostly flags)
@@ -124,6 +355,9 @@ for i in attacher,master provisioner,master resizer,master; do
     sed -i".bak" '/logtostderr/d' "${NEW_FILE}"
     # sed -i".bak" '/utilfeature.DefaultMutableFeatureGate/,/}/d' "${NEW_FILE}"
     sed -i".bak" '/^\tif !utilfeature\.DefaultMutableFeatureGate/,/^\t}/d' "${NEW_FILE}"
+    # The AIO sidecar sets the feature gates of every sidecar, see featuregates.Apply.
+    sed -i".bak" '/^\tif err := utilfeature\.DefaultMutableFeatureGate\.SetFromMap(featureGates); err != nil {$/,/^\t}$/d' "${NEW_FILE}"
+    use_featuregates ${SIDECAR} "${NEW_FILE}"
     sed -i".bak" '/flag.CommandLine.AddGoFlagSet/d' "${NEW_FILE}"
 
     # TODO: handle setting the automaxproc flag from each sidecar>
@@ -132,6 +366,28 @@ for i in attacher,master provisioner,master resizer,master; do
     sed -i".bak" '/standardflags.AddAutomaxprocs/d' "${NEW_FILE}"
     sed -i".bak" '/standardflags.RegisterCommonFlags/d' "${NEW_FILE}"
 
+    # Every sidecar mounts its mux on the diagnostics server owned by the AIO sidecar
+    # instead of listening on --http-endpoint on its own, with the registry of its
+    # metrics that the server merges on --metrics-path.
+    sed -i".bak" "s/http.ListenAndServe(addr, mux)/diagnosticsServer.ServeController(\"${SIDECAR}\", mux, metricsManager.GetRegistry())/g" "${NEW_FILE}"
+    # Report whether the controllers of every sidecar run, i.e. whether it's the leader.
+    sed -E -i".bak" "s/^(\s+)run := func\(ctx context.Context\) \{$/&\n\1\tdefer markRunning(\"${SIDECAR}\")()/" "${NEW_FILE}"
+    # The rate limiters built from the retry intervals read them from the queues
+    # package, so that a reload of --config changes them.
+    sed -E -i".bak" "s/workqueue\.NewTypedItemExponentialFailureRateLimiter(\[[^]]+\])\(\*retryIntervalStart, \*retryIntervalMax\)/queues.NewRetryRateLimiter\1(\"${SIDECAR}\")/g" "${NEW_FILE}"
+    if grep -q 'queues\.NewRetryRateLimiter' "${NEW_FILE}"; then
+      grep -q 'workqueue\.' "${NEW_FILE}" || sed -i".bak" '/"k8s.io\/client-go\/util\/workqueue"/d' "${NEW_FILE}"
+      grep -q '"github.com/kubernetes-csi/csi-sidecars/pkg/queues"' "${NEW_FILE}" ||
+        sed -i".bak" '0,/^import (/s//import (\n\t"github.com\/kubernetes-csi\/csi-sidecars\/pkg\/queues"/' "${NEW_FILE}"
+    fi
+    # The AIO sidecar reads the objects it needs from the informers of the sidecars,
+    # see useSidecarInformers.
+    sed -E -i".bak" "0,/^(\s+)(\w+) := informers\.NewSharedInformerFactory\(.*\)$/s//&\n\1useSidecarInformers(\"${SIDECAR}\", \2)/" "${NEW_FILE}"
+    # Log with the logger of the controller, see controllerContext.
+    sed -E -i".bak" 's/^\tlogger := klog.Background\(\)$/\tlogger := klog.FromContext(ctx)/' "${NEW_FILE}"
+    # Let the AIO sidecar customize the Kubernetes client config of every sidecar.
+    sed -E -i".bak" "s/^(\s+)config.Burst = \*kubeAPIBurst$/&\n\1customizeRestConfig(\"${SIDECAR}\", config)/" "${NEW_FILE}"
+
     # Dead imports
     sed -i".bak" '/goflag/d' "${NEW_FILE}"
     sed -i".bak" '/flag"/d' "${NEW_FILE}"
@@ -155,6 +411,38 @@ for i in attacher,master provisioner,master resizer,master; do
     # For more info read the comments that say `override`
     symlink_from_root_to_hack hack/pkg/attacher/cmd/csi-attacher/main.go
   fi
+  # The standalone sidecars read the feature gates of their own sidecar too.
+  use_featuregates ${SIDECAR} pkg/${SIDECAR}/cmd/csi-${SIDECAR}/*.go
+  # The standalone provisioner and resizer register their flags with the same
+  # config packages as the AIO binary, they're built later too.
+  if [[ "${SIDECAR}" == "provisioner" ]]; then
+    use_shared_config provisioner Provisioner goflag.CommandLine \
+      kubeAPICapacityQPS=KubeAPICapacityQPS:float32 \
+      kubeAPICapacityBurst=KubeAPICapacityBurst \
+      volumeNamePrefix=VolumeNamePrefix \
+      volumeNameUUIDLength=VolumeNameUUIDLength \
+      finalizerThreads=FinalizerThreads \
+      capacityThreads=CapacityThreads \
+      strictTopology=StrictTopology \
+      immediateTopology=ImmediateTopology \
+      extraCreateMetadata=ExtraCreateMetadata \
+      enableProfile=EnableProfile \
+      enableCapacity=EnableCapacity \
+      capacityImmediateBinding=CapacityImmediateBinding \
+      capacityPollInterval=CapacityPollInterval \
+      capacityOwnerrefLevel=CapacityOwnerrefLevel \
+      enableNodeDeployment=EnableNodeDeployment \
+      nodeDeploymentImmediateBinding=NodeDeploymentImmediateBinding \
+      nodeDeploymentBaseDelay=NodeDeploymentBaseDelay \
+      nodeDeploymentMaxDelay=NodeDeploymentMaxDelay \
+      controllerPublishReadOnly=ControllerPublishReadOnly \
+      preventVolumeModeConversion=PreventVolumeModeConversion
+  fi
+  if [[ "${SIDECAR}" == "resizer" ]]; then
+    use_shared_config resizer Resizer flag.CommandLine \
+      handleVolumeInUseError=HandleVolumeInUseError \
+      extraModifyMetadata=ExtraModifyMetadata
+  fi
 done
 
 # Sanity checks
@@ -170,10 +458,87 @@ fi
 
 # The new entrypoint for all the sidecars
 symlink_from_root_to_hack hack/cmd/csi-sidecars/main.go
+symlink_from_root_to_hack hack/cmd/csi-sidecars/main_test.go
+# Subcommands of the AIO binary, e.g. `csi-sidecars ctl`.
+symlink_from_root_to_hack hack/cmd/csi-sidecars/subcommands.go
+symlink_from_root_to_hack hack/cmd/csi-sidecars/probe.go
+symlink_from_root_to_hack hack/cmd/csi-sidecars/migrate/migrate.go
+symlink_from_root_to_hack hack/cmd/csi-sidecars/migrate/flags.go
+symlink_from_root_to_hack hack/cmd/csi-sidecars/migrate/migrate_test.go
+symlink_from_root_to_hack hack/cmd/csi-sidecars/ctl/ctl.go
+symlink_from_root_to_hack hack/cmd/csi-sidecars/ctl/client.go
 # The utility global function to register common and per-sidecar flags.
 symlink_from_root_to_hack hack/cmd/csi-sidecars/config/flags.go
+symlink_from_root_to_hack hack/cmd/csi-sidecars/config/aliases.go
+symlink_from_root_to_hack hack/cmd/csi-sidecars/config/file.go
+symlink_from_root_to_hack hack/cmd/csi-sidecars/config/configz.go
+symlink_from_root_to_hack hack/cmd/csi-sidecars/config/env.go
+symlink_from_root_to_hack hack/cmd/csi-sidecars/config/validate.go
 # The utility glofal functions to register attacher flags.
 symlink_from_root_to_hack hack/pkg/attacher/cmd/csi-attacher/config/flags.go
+symlink_from_root_to_hack hack/pkg/attacher/cmd/csi-attacher/config/flags_test.go
+# The utility global functions to register provisioner and resizer flags.
+symlink_from_root_to_hack hack/pkg/provisioner/cmd/csi-provisioner/config/flags.go
+symlink_from_root_to_hack hack/pkg/provisioner/cmd/csi-provisioner/config/flags_test.go
+symlink_from_root_to_hack hack/pkg/resizer/cmd/csi-resizer/config/flags.go
+symlink_from_root_to_hack hack/pkg/resizer/cmd/csi-resizer/config/flags_test.go
+# Metric cardinality options shared by every registry in the AIO binary.
+symlink_from_root_to_hack hack/cmd/csi-sidecars/metrics/metrics.go
+# Loggers of the controllers, see --<controller>-v.
+symlink_from_root_to_hack hack/cmd/csi-sidecars/logging/logging.go
+symlink_from_root_to_hack hack/cmd/csi-sidecars/logging/logging_test.go
+# The diagnostics server shared by every sidecar (--http-endpoint).
+symlink_from_root_to_hack hack/cmd/csi-sidecars/diagnostics/server.go
+symlink_from_root_to_hack hack/cmd/csi-sidecars/diagnostics/healthz.go
+symlink_from_root_to_hack hack/cmd/csi-sidecars/diagnostics/auth.go
+symlink_from_root_to_hack hack/cmd/csi-sidecars/diagnostics/controllers.go
+symlink_from_root_to_hack hack/cmd/csi-sidecars/diagnostics/metrics.go
+symlink_from_root_to_hack hack/cmd/csi-sidecars/diagnostics/server_test.go
+symlink_from_root_to_hack hack/cmd/csi-sidecars/diagnostics/healthz_test.go
+symlink_from_root_to_hack hack/cmd/csi-sidecars/diagnostics/auth_test.go
+symlink_from_root_to_hack hack/cmd/csi-sidecars/diagnostics/metrics_test.go
+# Customizations of the Kubernetes client config of every sidecar.
+symlink_from_root_to_hack hack/cmd/csi-sidecars/restconfig.go
+symlink_from_root_to_hack hack/cmd/csi-sidecars/admin.go
+symlink_from_root_to_hack hack/cmd/csi-sidecars/informers.go
+# Reload of the --config file.
+symlink_from_root_to_hack hack/cmd/csi-sidecars/reload.go
+symlink_from_root_to_hack hack/cmd/csi-sidecars/reload_test.go
+# The effective configuration served at /configz.
+symlink_from_root_to_hack hack/cmd/csi-sidecars/configz.go
+# The permissions of every controller checked at startup, see --rbac-check.
+symlink_from_root_to_hack hack/cmd/csi-sidecars/rbac/rbac.go
+symlink_from_root_to_hack hack/cmd/csi-sidecars/rbac/rbac_test.go
+# OpenTelemetry tracing, also imported by the sidecar controllers.
+symlink_from_root_to_hack hack/pkg/tracing/tracing.go
+symlink_from_root_to_hack hack/pkg/tracing/kubernetes.go
+symlink_from_root_to_hack hack/pkg/tracing/workqueue.go
+# Admin API, also imported by the sidecar controllers.
+symlink_from_root_to_hack hack/pkg/admin/admin.go
+symlink_from_root_to_hack hack/pkg/admin/pause.go
+symlink_from_root_to_hack hack/pkg/admin/requeue.go
+symlink_from_root_to_hack hack/pkg/admin/workers.go
+symlink_from_root_to_hack hack/pkg/admin/pause_test.go
+symlink_from_root_to_hack hack/pkg/admin/workers_test.go
+# Feature gates of every sidecar, also imported by the sidecar controllers.
+symlink_from_root_to_hack hack/pkg/featuregates/featuregates.go
+# Registry of the sidecar workqueues, also imported by the sidecar controllers.
+symlink_from_root_to_hack hack/pkg/queues/queues.go
+symlink_from_root_to_hack hack/pkg/queues/debug.go
+symlink_from_root_to_hack hack/pkg/queues/ratelimiter.go
+symlink_from_root_to_hack hack/pkg/queues/library.go
+symlink_from_root_to_hack hack/pkg/queues/debug_test.go
+symlink_from_root_to_hack hack/pkg/queues/library_test.go
+# Operation IDs of workqueue items, also imported by the sidecar controllers.
+symlink_from_root_to_hack hack/pkg/operations/operations.go
+symlink_from_root_to_hack hack/pkg/operations/grpc.go
+symlink_from_root_to_hack hack/pkg/operations/events.go
+symlink_from_root_to_hack hack/pkg/operations/running.go
+symlink_from_root_to_hack hack/pkg/operations/history.go
+symlink_from_root_to_hack hack/pkg/operations/rpc.go
+symlink_from_root_to_hack hack/pkg/operations/grpc_test.go
+symlink_from_root_to_hack hack/pkg/operations/events_test.go
+symlink_from_root_to_hack hack/pkg/operations/history_test.go
 
 # Create merged go.mod
 cat <<EOF >go.mod
@@ -228,6 +593,10 @@ make build
 # checkpoint for individual sidecar refactor: test that we can build attacher
 go build -a -ldflags ' -X main.version=foo -extldflags "-static"' -o ./bin/csi-attacher ./pkg/attacher/cmd/csi-attacher
 ./bin/csi-attacher --help || true
+go build -a -ldflags ' -X main.version=foo -extldflags "-static"' -o ./bin/csi-provisioner ./pkg/provisioner/cmd/csi-provisioner
+./bin/csi-provisioner --help || true
+go build -a -ldflags ' -X main.version=foo -extldflags "-static"' -o ./bin/csi-resizer ./pkg/resizer/cmd/csi-resizer
+./bin/csi-resizer --help || true
 
 # cat <<'EOF' >Dockerfile
 # FROM gcr.io/distroless/static:latest
@@ -0,0 +1,100 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package admin implements the admin API of the CSI sidecars, served on
+// --http-endpoint under PathPrefix when --enable-admin-api is set:
+//
+//	GET  /admin/pauses
+//	POST /admin/pause, /admin/resume
+//	POST /admin/controllers/<controller>/pause, .../resume
+//	POST /admin/controllers/<controller>/requeue?kind=<kind>&namespace=<namespace>&name=<name>
+//	POST /admin/controllers/<controller>/resync
+//	POST /admin/nodes/<node>/pause, .../resume
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// PathPrefix is where Handler is meant to be mounted.
+const PathPrefix = "/admin/"
+
+// Handler serves the admin API.
+func Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/pauses", servePauses)
+	mux.HandleFunc("POST /admin/{action}", func(w http.ResponseWriter, r *http.Request) {
+		setPaused(w, r, ScopeGlobal, "")
+	})
+	mux.HandleFunc("POST /admin/controllers/{name}/{action}", func(w http.ResponseWriter, r *http.Request) {
+		var enqueued []Enqueued
+		var err error
+		switch r.PathValue("action") {
+		case "requeue":
+			query := r.URL.Query()
+			enqueued, err = Requeue(r.PathValue("name"), query.Get("kind"), query.Get("namespace"), query.Get("name"))
+		case "resync":
+			enqueued, err = Resync(r.PathValue("name"))
+		default:
+			setPaused(w, r, ScopeController, r.PathValue("name"))
+			return
+		}
+		if errors.Is(err, ErrUnsupported) {
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, enqueued)
+	})
+	mux.HandleFunc("POST /admin/nodes/{name}/{action}", func(w http.ResponseWriter, r *http.Request) {
+		setPaused(w, r, ScopeNode, r.PathValue("name"))
+	})
+	return mux
+}
+
+func setPaused(w http.ResponseWriter, r *http.Request, scope, name string) {
+	var paused bool
+	switch r.PathValue("action") {
+	case "pause":
+		paused = true
+	case "resume":
+		paused = false
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if err := SetPaused(scope, name, paused); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	servePauses(w, r)
+}
+
+func servePauses(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, Pauses())
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
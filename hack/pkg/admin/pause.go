@@ -0,0 +1,189 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+)
+
+// Scopes a pause applies to.
+const (
+	ScopeGlobal     = "global"
+	ScopeController = "controller"
+	ScopeNode       = "node"
+)
+
+// Pause is an active pause.
+type Pause struct {
+	Scope string    `json:"scope"`
+	Name  string    `json:"name,omitempty"`
+	Since time.Time `json:"since"`
+}
+
+// NodeResolver returns the node an object is bound to, or an empty string
+// when it isn't bound to a node or isn't known.
+type NodeResolver func(namespace, name string) string
+
+var pausedGauge = metrics.NewGaugeVec(
+	&metrics.GaugeOpts{
+		Name:           "csi_sidecar_paused",
+		Help:           "1 while processing is paused for the scope, see the admin API.",
+		StabilityLevel: metrics.ALPHA,
+	},
+	[]string{"scope", "name"},
+)
+
+func init() {
+	legacyregistry.MustRegister(pausedGauge)
+	// The global series is exported while nothing is paused too, so that
+	// alerts on it don't need absent().
+	pausedGauge.WithLabelValues(ScopeGlobal, "").Set(0)
+}
+
+var (
+	mu            sync.Mutex
+	pauses        = map[pauseKey]time.Time{}
+	nodeResolvers = map[string]NodeResolver{}
+)
+
+type pauseKey struct {
+	scope string
+	name  string
+}
+
+// RegisterNodeResolver sets how the node of objects of kind is found, it
+// enables pausing their processing per node.
+func RegisterNodeResolver(kind string, resolver NodeResolver) {
+	mu.Lock()
+	defer mu.Unlock()
+	nodeResolvers[kind] = resolver
+}
+
+// SetPaused pauses or resumes the processing of scope. name is the
+// controller or node name and is ignored for ScopeGlobal.
+func SetPaused(scope, name string, paused bool) error {
+	switch scope {
+	case ScopeGlobal:
+		name = ""
+	case ScopeController, ScopeNode:
+		if name == "" {
+			return fmt.Errorf("the %s name is missing", scope)
+		}
+	default:
+		return fmt.Errorf("unknown pause scope %q", scope)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	key := pauseKey{scope: scope, name: name}
+	if paused {
+		if _, ok := pauses[key]; !ok {
+			pauses[key] = time.Now()
+			klog.InfoS("Paused processing", "scope", scope, "name", name)
+		}
+		pausedGauge.WithLabelValues(scope, name).Set(1)
+		return nil
+	}
+	if _, ok := pauses[key]; ok {
+		delete(pauses, key)
+		klog.InfoS("Resumed processing", "scope", scope, "name", name)
+	}
+	pausedGauge.WithLabelValues(scope, name).Set(0)
+	return nil
+}
+
+// Pauses returns the active pauses.
+func Pauses() []Pause {
+	mu.Lock()
+	defer mu.Unlock()
+	list := make([]Pause, 0, len(pauses))
+	for key, since := range pauses {
+		list = append(list, Pause{Scope: key.scope, Name: key.name, Since: since})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Scope != list[j].Scope {
+			return list[i].Scope < list[j].Scope
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// PausedSummary describes the active pauses for /healthz, e.g.
+// "controller/attacher,node/node-1", or returns an empty string.
+func PausedSummary() string {
+	var names []string
+	for _, p := range Pauses() {
+		if p.Name == "" {
+			names = append(names, p.Scope)
+		} else {
+			names = append(names, p.Scope+"/"+p.Name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// PausedFor returns the scope that pauses the processing of an object by
+// controller, globally, for the controller or for the node of the object.
+// The workers don't wait for a resume, the object is requeued instead, see
+// operations.Start. Informers and leader election keep running.
+func PausedFor(controller, kind, namespace, name string) (string, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	return pausedFor(controller, kind, namespace, name)
+}
+
+// pausedFor returns the scope that pauses an object, mu must be held.
+func pausedFor(controller, kind, namespace, name string) (string, bool) {
+	if _, ok := pauses[pauseKey{scope: ScopeGlobal}]; ok {
+		return ScopeGlobal, true
+	}
+	if _, ok := pauses[pauseKey{scope: ScopeController, name: controller}]; ok {
+		return ScopeController, true
+	}
+	if !hasNodePauses() {
+		return "", false
+	}
+	resolver, ok := nodeResolvers[kind]
+	if !ok {
+		return "", false
+	}
+	if node := resolver(namespace, name); node != "" {
+		if _, ok := pauses[pauseKey{scope: ScopeNode, name: node}]; ok {
+			return ScopeNode, true
+		}
+	}
+	return "", false
+}
+
+// hasNodePauses avoids resolving nodes while no node is paused, mu must be
+// held.
+func hasNodePauses() bool {
+	for key := range pauses {
+		if key.scope == ScopeNode {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admin
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/component-base/metrics/testutil"
+)
+
+// resetPauses removes the pauses and node resolvers once the test is done.
+func resetPauses(t *testing.T) {
+	t.Cleanup(func() {
+		for _, p := range Pauses() {
+			if err := SetPaused(p.Scope, p.Name, false); err != nil {
+				t.Error(err)
+			}
+		}
+		mu.Lock()
+		nodeResolvers = map[string]NodeResolver{}
+		mu.Unlock()
+	})
+}
+
+func TestPausedMetricRegistered(t *testing.T) {
+	// The global series is exported before anything is paused.
+	expected := `
+# HELP csi_sidecar_paused [ALPHA] 1 while processing is paused for the scope, see the admin API.
+# TYPE csi_sidecar_paused gauge
+csi_sidecar_paused{name="",scope="global"} 0
+`
+	if err := testutil.GatherAndCompare(legacyregistry.DefaultGatherer, strings.NewReader(expected), "csi_sidecar_paused"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPausedFor(t *testing.T) {
+	nodes := map[string]string{"va-1": "node-1", "va-2": "node-2"}
+	resolved := 0
+
+	tests := []struct {
+		name   string
+		pauses []Pause
+		// The paused object of the attacher, a VolumeAttachment.
+		object        string
+		expectedScope string
+		// expectResolve is set when the node of the object has to be
+		// resolved.
+		expectResolve bool
+	}{
+		{
+			name:   "not paused",
+			object: "va-1",
+		},
+		{
+			name:          "global",
+			pauses:        []Pause{{Scope: ScopeGlobal}},
+			object:        "va-1",
+			expectedScope: ScopeGlobal,
+		},
+		{
+			name:          "controller",
+			pauses:        []Pause{{Scope: ScopeController, Name: "attacher"}},
+			object:        "va-1",
+			expectedScope: ScopeController,
+		},
+		{
+			name:   "other controller",
+			pauses: []Pause{{Scope: ScopeController, Name: "resizer"}},
+			object: "va-1",
+		},
+		{
+			name:          "node",
+			pauses:        []Pause{{Scope: ScopeNode, Name: "node-1"}},
+			object:        "va-1",
+			expectedScope: ScopeNode,
+			expectResolve: true,
+		},
+		{
+			name:          "other node",
+			pauses:        []Pause{{Scope: ScopeNode, Name: "node-1"}},
+			object:        "va-2",
+			expectResolve: true,
+		},
+		{
+			name:          "unknown object",
+			pauses:        []Pause{{Scope: ScopeNode, Name: "node-1"}},
+			object:        "va-3",
+			expectResolve: true,
+		},
+		{
+			name:          "global before node",
+			pauses:        []Pause{{Scope: ScopeNode, Name: "node-1"}, {Scope: ScopeGlobal}},
+			object:        "va-1",
+			expectedScope: ScopeGlobal,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resetPauses(t)
+			RegisterNodeResolver("VolumeAttachment", func(namespace, name string) string {
+				resolved++
+				return nodes[name]
+			})
+			for _, p := range test.pauses {
+				if err := SetPaused(p.Scope, p.Name, true); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			resolved = 0
+			scope, paused := PausedFor("attacher", "VolumeAttachment", "", test.object)
+			if scope != test.expectedScope || paused != (test.expectedScope != "") {
+				t.Errorf("expected the scope %q, got %q and paused %t", test.expectedScope, scope, paused)
+			}
+			if (resolved > 0) != test.expectResolve {
+				t.Errorf("expected the node to be resolved: %t, got %d resolutions", test.expectResolve, resolved)
+			}
+		})
+	}
+}
+
+func TestPausedForKindWithoutResolver(t *testing.T) {
+	resetPauses(t)
+	if err := SetPaused(ScopeNode, "node-1", true); err != nil {
+		t.Fatal(err)
+	}
+	// The node of a PersistentVolume isn't known, it's never paused per node.
+	if scope, paused := PausedFor("resizer", "PersistentVolume", "", "pv-1"); paused {
+		t.Errorf("expected a PersistentVolume not to be paused, got the scope %s", scope)
+	}
+}
+
+func TestSetPaused(t *testing.T) {
+	resetPauses(t)
+
+	for _, test := range []struct {
+		scope, name string
+	}{
+		{scope: ScopeController},
+		{scope: ScopeNode},
+		{scope: "zone", name: "a"},
+	} {
+		if err := SetPaused(test.scope, test.name, true); err == nil {
+			t.Errorf("expected an error pausing %s %q", test.scope, test.name)
+		}
+	}
+
+	before := time.Now()
+	for _, p := range []Pause{
+		{Scope: ScopeNode, Name: "node-1"},
+		{Scope: ScopeController, Name: "attacher"},
+		// The name of a global pause is ignored.
+		{Scope: ScopeGlobal, Name: "ignored"},
+	} {
+		if err := SetPaused(p.Scope, p.Name, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if summary, expected := PausedSummary(), "controller/attacher,global,node/node-1"; summary != expected {
+		t.Errorf("expected the pauses %q, got %q", expected, summary)
+	}
+	// Pausing again keeps when the pause started.
+	since := Pauses()[0].Since
+	if err := SetPaused(ScopeController, "attacher", true); err != nil {
+		t.Fatal(err)
+	}
+	if p := Pauses()[0]; !p.Since.Equal(since) || p.Since.Before(before) {
+		t.Errorf("expected the pause to start at %s, got %s", since, p.Since)
+	}
+
+	for _, p := range Pauses() {
+		if value, err := testutil.GetGaugeMetricValue(pausedGauge.WithLabelValues(p.Scope, p.Name)); err != nil || value != 1 {
+			t.Errorf("expected csi_sidecar_paused of %s %q to be 1, got %v: %v", p.Scope, p.Name, value, err)
+		}
+	}
+
+	if err := SetPaused(ScopeGlobal, "", false); err != nil {
+		t.Fatal(err)
+	}
+	if summary, expected := PausedSummary(), "controller/attacher,node/node-1"; summary != expected {
+		t.Errorf("expected the pauses %q, got %q", expected, summary)
+	}
+	if value, err := testutil.GetGaugeMetricValue(pausedGauge.WithLabelValues(ScopeGlobal, "")); err != nil || value != 0 {
+		t.Errorf("expected csi_sidecar_paused of the global scope to be 0, got %v: %v", value, err)
+	}
+}
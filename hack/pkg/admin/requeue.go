@@ -0,0 +1,122 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admin
+
+import (
+	"errors"
+	"fmt"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubernetes-csi/csi-sidecars/pkg/queues"
+)
+
+// Lister returns the queue keys of every object of a kind, see queues.Key.
+type Lister func() ([]string, error)
+
+var listers = map[string]Lister{}
+
+// RegisterLister sets how the objects of kind are listed, it enables
+// resyncing the queues holding them.
+func RegisterLister(kind string, lister Lister) {
+	mu.Lock()
+	defer mu.Unlock()
+	listers[kind] = lister
+}
+
+// ErrUnsupported is returned for the controllers whose queues can't be
+// registered, see queues.MarkUnregistered.
+var ErrUnsupported = errors.New("not supported")
+
+// checkRegistered returns ErrUnsupported when the queues of controller can't
+// be registered.
+func checkRegistered(controller string) error {
+	if reason, ok := queues.Unregistered(controller); ok {
+		return fmt.Errorf("requeue and resync are %w by the %s controller: %s", ErrUnsupported, controller, reason)
+	}
+	return nil
+}
+
+// Enqueued is the result of a requeue or resync.
+type Enqueued struct {
+	Controller string `json:"controller"`
+	Queue      string `json:"queue"`
+	Kind       string `json:"kind"`
+	Items      int    `json:"items"`
+}
+
+// Requeue adds an object to the queues of controller holding its kind right
+// away, bypassing their rate limiters.
+func Requeue(controller, kind, namespace, name string) ([]Enqueued, error) {
+	if kind == "" || name == "" {
+		return nil, fmt.Errorf("the kind and name of the object are required")
+	}
+	if err := checkRegistered(controller); err != nil {
+		return nil, err
+	}
+	qs := queues.ForController(controller, kind)
+	if len(qs) == 0 {
+		return nil, fmt.Errorf("controller %q has no queue of %s", controller, kind)
+	}
+	key := queues.Key(namespace, name)
+	var result []Enqueued
+	for _, q := range qs {
+		if err := q.Enqueue(key); err != nil {
+			return result, err
+		}
+		klog.InfoS("Requeued object", "controller", controller, "queue", q.Name(), "kind", kind, "key", key)
+		result = append(result, Enqueued{Controller: controller, Queue: q.Name(), Kind: kind, Items: 1})
+	}
+	return result, nil
+}
+
+// Resync adds every object to the queues of controller, like a resync of
+// its informers does.
+func Resync(controller string) ([]Enqueued, error) {
+	if err := checkRegistered(controller); err != nil {
+		return nil, err
+	}
+	qs := queues.ForController(controller, "")
+	if len(qs) == 0 {
+		return nil, fmt.Errorf("controller %q has no registered queue", controller)
+	}
+	var result []Enqueued
+	var errs []error
+	for _, q := range qs {
+		mu.Lock()
+		lister, ok := listers[q.Kind()]
+		mu.Unlock()
+		if !ok {
+			errs = append(errs, fmt.Errorf("queue %s of %s: objects of kind %q can't be listed", q.Name(), controller, q.Kind()))
+			continue
+		}
+		keys, err := lister()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("queue %s of %s: %w", q.Name(), controller, err))
+			continue
+		}
+		for _, key := range keys {
+			if err := q.Enqueue(key); err != nil {
+				errs = append(errs, err)
+				break
+			}
+		}
+		klog.InfoS("Resynced queue", "controller", controller, "queue", q.Name(), "items", len(keys))
+		result = append(result, Enqueued{Controller: controller, Queue: q.Name(), Kind: q.Kind(), Items: len(keys)})
+	}
+	return result, errors.Join(errs...)
+}
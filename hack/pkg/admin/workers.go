@@ -0,0 +1,78 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admin
+
+import (
+	"sync"
+
+	"k8s.io/klog/v2"
+)
+
+var (
+	workersMu sync.Mutex
+	workers   = map[string]*workerLimit{}
+)
+
+type workerLimit struct {
+	limit  int
+	active int
+}
+
+// SetWorkerLimit sets how many items controller processes at once, 0 removes
+// the limit. The sidecars start their workers once, so the limit can only
+// lower the number of workers they started with, e.g. when the worker count
+// of a controller is reloaded.
+func SetWorkerLimit(controller string, limit int) {
+	workersMu.Lock()
+	defer workersMu.Unlock()
+	w, ok := workers[controller]
+	if !ok {
+		w = &workerLimit{}
+		workers[controller] = w
+	}
+	if w.limit != limit {
+		klog.InfoS("Changed the worker limit", "controller", controller, "limit", limit)
+	}
+	w.limit = limit
+}
+
+// TryAcquireWorker returns false while controller processes as many items as
+// its worker limit allows, the item is requeued instead of waiting for a
+// worker, see operations.Start. Otherwise the returned function releases the
+// worker.
+func TryAcquireWorker(controller string) (func(), bool) {
+	workersMu.Lock()
+	defer workersMu.Unlock()
+	w, ok := workers[controller]
+	if !ok {
+		w = &workerLimit{}
+		workers[controller] = w
+	}
+	if w.limit > 0 && w.active >= w.limit {
+		return nil, false
+	}
+	w.active++
+	return func() { releaseWorker(controller) }, true
+}
+
+func releaseWorker(controller string) {
+	workersMu.Lock()
+	defer workersMu.Unlock()
+	if w, ok := workers[controller]; ok && w.active > 0 {
+		w.active--
+	}
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admin
+
+import "testing"
+
+func TestWorkerLimit(t *testing.T) {
+	t.Cleanup(func() {
+		workersMu.Lock()
+		delete(workers, "attacher")
+		workersMu.Unlock()
+	})
+
+	// Without a limit every worker the sidecar started processes items.
+	var releases []func()
+	for range 3 {
+		release, ok := TryAcquireWorker("attacher")
+		if !ok {
+			t.Fatal("expected a worker without a limit")
+		}
+		releases = append(releases, release)
+	}
+
+	// A lower limit only takes effect once enough workers are released.
+	SetWorkerLimit("attacher", 2)
+	if _, ok := TryAcquireWorker("attacher"); ok {
+		t.Error("expected no worker while 3 workers are active with the limit 2")
+	}
+	releases[0]()
+	if _, ok := TryAcquireWorker("attacher"); ok {
+		t.Error("expected no worker while 2 workers are active with the limit 2")
+	}
+	releases[1]()
+	if _, ok := TryAcquireWorker("attacher"); !ok {
+		t.Fatal("expected a worker while 1 worker is active with the limit 2")
+	}
+
+	// Removing the limit frees the workers again.
+	SetWorkerLimit("attacher", 0)
+	if _, ok := TryAcquireWorker("attacher"); !ok {
+		t.Error("expected a worker once the limit is removed")
+	}
+
+	// The workers of the other controllers aren't limited.
+	SetWorkerLimit("attacher", 1)
+	if _, ok := TryAcquireWorker("resizer"); !ok {
+		t.Error("expected a worker of the resizer")
+	}
+	workersMu.Lock()
+	delete(workers, "resizer")
+	workersMu.Unlock()
+}
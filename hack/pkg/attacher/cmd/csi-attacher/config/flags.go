@@ -1,7 +1,9 @@
 package config
 
 import (
+	"errors"
 	"flag"
+	"fmt"
 	"time"
 )
 
@@ -14,12 +16,16 @@ type AttacherConfiguration struct {
 	Timeout            time.Duration
 	RetryIntervalStart time.Duration
 	RetryIntervalMax   time.Duration
+
+	// prefix of the flags, see Validate.
+	prefix string
 }
 
 func registerAttacherFlags(flags *flag.FlagSet, configuration *AttacherConfiguration, prefix string) {
-	flag.IntVar(&configuration.MaxEntries, prefix+"max-entries", 0, "Max entries per each page in volume lister call, 0 means no limit.")
-	flag.DurationVar(&configuration.ReconcileSync, prefix+"reconcile-sync", 1*time.Minute, "Resync interval of the VolumeAttachment reconciler.")
-	flag.IntVar(&configuration.MaxGRPCLogLength, prefix+"max-grpc-log-length", -1, "The maximum amount of characters logged for every grpc responses. Defaults to no limit")
+	configuration.prefix = prefix
+	flags.IntVar(&configuration.MaxEntries, prefix+"max-entries", 0, "Max entries per each page in volume lister call, 0 means no limit.")
+	flags.DurationVar(&configuration.ReconcileSync, prefix+"reconcile-sync", 1*time.Minute, "Resync interval of the VolumeAttachment reconciler.")
+	flags.IntVar(&configuration.MaxGRPCLogLength, prefix+"max-grpc-log-length", -1, "The maximum amount of characters logged for every grpc responses. Defaults to no limit")
 	flags.IntVar(&configuration.WorkerThreads, prefix+"worker-threads", 10, "Number of worker threads per sidecar")
 	flags.StringVar(&configuration.DefaultFSType, prefix+"default-fstype", "", "The default filesystem type of the volume to use.")
 	flags.DurationVar(&configuration.Timeout, prefix+"timeout", 15*time.Second, "Timeout for waiting for attaching or detaching the volume.")
@@ -34,3 +40,36 @@ func RegisterAttacherFlags(flags *flag.FlagSet, configuration *AttacherConfigura
 func RegisterAttacherFlagsWithPrefix(flags *flag.FlagSet, configuration *AttacherConfiguration) {
 	registerAttacherFlags(flags, configuration, "attacher-")
 }
+
+// Validate returns every invalid flag value of the configuration.
+func (c *AttacherConfiguration) Validate() error {
+	var errs []error
+	if c.WorkerThreads <= 0 {
+		errs = append(errs, fmt.Errorf("--%sworker-threads must be greater than zero", c.prefix))
+	}
+	if c.MaxEntries < 0 {
+		errs = append(errs, fmt.Errorf("--%smax-entries must not be negative", c.prefix))
+	}
+	for _, d := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"reconcile-sync", c.ReconcileSync},
+		{"timeout", c.Timeout},
+	} {
+		if d.value < 0 {
+			errs = append(errs, fmt.Errorf("--%s%s must not be negative", c.prefix, d.name))
+		}
+	}
+	// The AIO binary reads --retry-interval-start and --retry-interval-max
+	// instead of the prefixed retry intervals.
+	if c.prefix == "" {
+		if c.RetryIntervalStart < 0 || c.RetryIntervalMax < 0 {
+			errs = append(errs, errors.New("--retry-interval-start and --retry-interval-max must not be negative"))
+		}
+		if c.RetryIntervalStart > c.RetryIntervalMax {
+			errs = append(errs, errors.New("--retry-interval-start must not be greater than --retry-interval-max"))
+		}
+	}
+	return errors.Join(errs...)
+}
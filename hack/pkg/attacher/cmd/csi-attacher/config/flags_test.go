@@ -0,0 +1,93 @@
+package config
+
+import (
+	"flag"
+	"testing"
+	"time"
+)
+
+func TestRegisterAttacherFlags(t *testing.T) {
+	defaults := AttacherConfiguration{
+		ReconcileSync:      time.Minute,
+		MaxGRPCLogLength:   -1,
+		WorkerThreads:      10,
+		Timeout:            15 * time.Second,
+		RetryIntervalStart: time.Second,
+		RetryIntervalMax:   5 * time.Minute,
+	}
+	configured := AttacherConfiguration{
+		MaxEntries:         100,
+		ReconcileSync:      2 * time.Minute,
+		MaxGRPCLogLength:   1000,
+		WorkerThreads:      20,
+		DefaultFSType:      "ext4",
+		Timeout:            30 * time.Second,
+		RetryIntervalStart: 2 * time.Second,
+		RetryIntervalMax:   time.Minute,
+	}
+	args := []string{
+		"max-entries=100",
+		"reconcile-sync=2m",
+		"max-grpc-log-length=1000",
+		"worker-threads=20",
+		"default-fstype=ext4",
+		"timeout=30s",
+		"retry-interval-start=2s",
+		"retry-interval-max=1m",
+	}
+	withPrefix := func(c AttacherConfiguration) AttacherConfiguration {
+		c.prefix = "attacher-"
+		return c
+	}
+	prefixed := func(prefix string) []string {
+		var prefixed []string
+		for _, arg := range args {
+			prefixed = append(prefixed, "--"+prefix+arg)
+		}
+		return prefixed
+	}
+
+	tests := []struct {
+		name     string
+		register func(*flag.FlagSet, *AttacherConfiguration)
+		args     []string
+		expected AttacherConfiguration
+	}{
+		{
+			name:     "unprefixed defaults",
+			register: RegisterAttacherFlags,
+			expected: defaults,
+		},
+		{
+			name:     "unprefixed",
+			register: RegisterAttacherFlags,
+			args:     prefixed(""),
+			expected: configured,
+		},
+		{
+			name:     "prefixed defaults",
+			register: RegisterAttacherFlagsWithPrefix,
+			expected: withPrefix(defaults),
+		},
+		{
+			name:     "prefixed",
+			register: RegisterAttacherFlagsWithPrefix,
+			args:     prefixed("attacher-"),
+			expected: withPrefix(configured),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			var c AttacherConfiguration
+			test.register(flags, &c)
+			if err := flags.Parse(test.args); err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if c != test.expected {
+				t.Errorf("expected %+v, got %+v", test.expected, c)
+			}
+		})
+	}
+}
@@ -29,7 +29,6 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apiserver/pkg/server"
 
-	utilfeature "k8s.io/apiserver/pkg/util/feature"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -57,6 +56,7 @@ import (
 	"github.com/kubernetes-csi/csi-sidecars/pkg/attacher/pkg/attacher"
 	"github.com/kubernetes-csi/csi-sidecars/pkg/attacher/pkg/controller"
 	"github.com/kubernetes-csi/csi-sidecars/pkg/attacher/pkg/features"
+	"github.com/kubernetes-csi/csi-sidecars/pkg/featuregates"
 	"google.golang.org/grpc"
 )
 
@@ -106,7 +106,7 @@ var version = "unknown"
 
 func main() {
 	flag.Var(utilflag.NewMapStringBool(&featureGates), "feature-gates", "A set of key=value pairs that describe feature gates for alpha/experimental features. "+
-		"Options are:\n"+strings.Join(utilfeature.DefaultFeatureGate.KnownFeatures(), "\n"))
+		"Options are:\n"+strings.Join(featuregates.For("attacher").KnownFeatures(), "\n"))
 
 	fg := featuregate.NewFeatureGate()
 	logsapi.AddFeatureGates(fg)
@@ -137,7 +137,7 @@ func main() {
 		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
 	}
 
-	if err := utilfeature.DefaultMutableFeatureGate.SetFromMap(featureGates); err != nil {
+	if err := featuregates.For("attacher").SetFromMap(featureGates); err != nil {
 		logger.Error(err, "failed to store flag gates", "featureGates", featureGates)
 		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
 	}
@@ -167,8 +167,10 @@ func main() {
 	config.Burst = *kubeAPIBurst
 	config.ContentType = runtime.ContentTypeProtobuf
 
-	if *workerThreads == 0 {
-		logger.Error(nil, "Option -worker-threads must be greater than zero")
+	// override: the flags are validated together, e.g. -worker-threads must be
+	// greater than zero.
+	if err := attacherConfiguration.Validate(); err != nil {
+		logger.Error(err, "Invalid configuration")
 		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
 	}
 
@@ -321,7 +323,7 @@ func main() {
 		shutdownHandler <-chan struct{} // called when the signal is received
 	)
 
-	if utilfeature.DefaultFeatureGate.Enabled(features.ReleaseLeaderElectionOnExit) {
+	if featuregates.For("attacher").Enabled(features.ReleaseLeaderElectionOnExit) {
 		ctx, terminate = context.WithCancel(ctx) // shuts down the whole process, incl. leader election
 		var cancelControllerCtx context.CancelFunc
 		controllerCtx, cancelControllerCtx = context.WithCancel(ctx)
@@ -337,7 +339,7 @@ func main() {
 	}
 
 	run := func(ctx context.Context) {
-		if utilfeature.DefaultFeatureGate.Enabled(features.ReleaseLeaderElectionOnExit) {
+		if featuregates.For("attacher").Enabled(features.ReleaseLeaderElectionOnExit) {
 			var wg sync.WaitGroup
 			factory.Start(shutdownHandler)
 			ctrl.Run(controllerCtx, int(*workerThreads), &wg)
@@ -376,7 +378,7 @@ func main() {
 		le.WithLeaseDuration(*leaderElectionLeaseDuration)
 		le.WithRenewDeadline(*leaderElectionRenewDeadline)
 		le.WithRetryPeriod(*leaderElectionRetryPeriod)
-		if utilfeature.DefaultFeatureGate.Enabled(features.ReleaseLeaderElectionOnExit) {
+		if featuregates.For("attacher").Enabled(features.ReleaseLeaderElectionOnExit) {
 			le.WithReleaseOnCancel(true)
 			le.WithContext(ctx)
 		}
@@ -0,0 +1,200 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package featuregates gives every sidecar its own feature gates so that a
+// gate with the same name in two sidecars can be set independently.
+// do_sync.sh rewrites the uses of utilfeature.DefaultFeatureGate in the
+// sidecars to For("<sidecar>").
+package featuregates
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	"k8s.io/component-base/featuregate"
+)
+
+var (
+	mu    sync.Mutex
+	gates = map[string]featuregate.MutableFeatureGate{}
+)
+
+// For returns the feature gates of a controller, they're created on first
+// use, usually by the init functions of the features package of the sidecar.
+func For(controller string) featuregate.MutableFeatureGate {
+	mu.Lock()
+	defer mu.Unlock()
+	gate, ok := gates[controller]
+	if !ok {
+		gate = featuregate.NewFeatureGate()
+		gates[controller] = gate
+	}
+	return gate
+}
+
+// Controllers returns the controllers that have feature gates, sorted.
+func Controllers() []string {
+	mu.Lock()
+	defer mu.Unlock()
+	controllers := make([]string, 0, len(gates))
+	for controller := range gates {
+		controllers = append(controllers, controller)
+	}
+	sort.Strings(controllers)
+	return controllers
+}
+
+func lookup(controller string) (featuregate.MutableFeatureGate, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	gate, ok := gates[controller]
+	return gate, ok
+}
+
+// Gates is the value of --feature-gates, the gates set by controller, e.g.
+// attacher:ReleaseLeaderElectionOnExit=true. Gates set without a controller
+// are stored under "".
+type Gates map[string]map[string]bool
+
+// String implements flag.Value.
+func (g *Gates) String() string {
+	if g == nil {
+		return ""
+	}
+	var pairs []string
+	for controller, values := range *g {
+		for name, enabled := range values {
+			if controller != "" {
+				name = controller + ":" + name
+			}
+			pairs = append(pairs, fmt.Sprintf("%s=%t", name, enabled))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Set implements flag.Value, the gates of every occurrence of the flag are
+// merged.
+func (g *Gates) Set(value string) error {
+	if *g == nil {
+		*g = Gates{}
+	}
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, raw, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("missing bool value for %s", pair)
+		}
+		enabled, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("invalid value of %s=%s, err: %v", key, raw, err)
+		}
+		controller, name, ok := strings.Cut(strings.TrimSpace(key), ":")
+		if !ok {
+			controller, name = "", controller
+		}
+		if (*g)[controller] == nil {
+			(*g)[controller] = map[string]bool{}
+		}
+		(*g)[controller][strings.TrimSpace(name)] = enabled
+	}
+	return nil
+}
+
+// Type implements pflag.Value.
+func (g *Gates) Type() string {
+	return "mapStringBool"
+}
+
+// Apply sets the feature gates of every controller. A gate set without a
+// controller is set in every controller that knows it, or in
+// utilfeature.DefaultMutableFeatureGate when none does.
+func Apply(g Gates) error {
+	var errs []error
+	controllers := make([]string, 0, len(g))
+	for controller := range g {
+		if controller != "" {
+			controllers = append(controllers, controller)
+		}
+	}
+	sort.Strings(controllers)
+	for _, controller := range controllers {
+		gate, ok := lookup(controller)
+		if !ok {
+			errs = append(errs, fmt.Errorf("unknown controller %q in --feature-gates, the controllers with feature gates are: %s", controller, strings.Join(Controllers(), ", ")))
+			continue
+		}
+		if err := gate.SetFromMap(g[controller]); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", controller, err))
+		}
+	}
+
+	names := make([]string, 0, len(g[""]))
+	for name := range g[""] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		values := map[string]bool{name: g[""][name]}
+		known := false
+		for _, controller := range Controllers() {
+			gate := For(controller)
+			if _, ok := gate.GetAll()[featuregate.Feature(name)]; !ok {
+				continue
+			}
+			known = true
+			if err := gate.SetFromMap(values); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", controller, err))
+			}
+		}
+		if !known {
+			if err := utilfeature.DefaultMutableFeatureGate.SetFromMap(values); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Usage is the help of --feature-gates, it lists the gates of every
+// controller and the ones that aren't specific to a controller, e.g. the
+// logging gates.
+func Usage() string {
+	var b strings.Builder
+	b.WriteString("A set of key=value pairs that describe feature gates for alpha/experimental features, " +
+		"prefixed by the controller they apply to, e.g. attacher:ReleaseLeaderElectionOnExit=true. " +
+		"A gate without a controller is set in every controller that has it. Options are:")
+	for _, controller := range Controllers() {
+		fmt.Fprintf(&b, "\n%s:", controller)
+		for _, feature := range For(controller).KnownFeatures() {
+			fmt.Fprintf(&b, "\n  %s", feature)
+		}
+	}
+	b.WriteString("\nwithout a controller:")
+	for _, feature := range utilfeature.DefaultFeatureGate.KnownFeatures() {
+		fmt.Fprintf(&b, "\n  %s", feature)
+	}
+	return b.String()
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operations
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/klog/v2"
+)
+
+// Annotations added to the Events emitted for an object, they identify the
+// latest operation started for the object.
+const (
+	OperationIDAnnotation = "csi.storage.k8s.io/operation-id"
+	ControllerAnnotation  = "csi.storage.k8s.io/controller"
+)
+
+// AnnotateEvents returns a rest.Config WrapperFunc that annotates the Events
+// created through the client with the latest operation of their involved
+// object, e.g.
+//
+//	config.Wrap(operations.AnnotateEvents)
+//
+// The event recorders of the sidecars don't receive a context, so the
+// operation is looked up by the object reference of the Event.
+func AnnotateEvents(rt http.RoundTripper) http.RoundTripper {
+	return &eventsRoundTripper{delegate: rt}
+}
+
+type eventsRoundTripper struct {
+	delegate http.RoundTripper
+}
+
+func (rt *eventsRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.Method != http.MethodPost || r.Body == nil || !strings.HasSuffix(r.URL.Path, "/events") {
+		return rt.delegate.RoundTrip(r)
+	}
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if annotated, ok := annotateEvent(r.Header.Get("Content-Type"), body); ok {
+		body = annotated
+	}
+	r = r.Clone(r.Context())
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return rt.delegate.RoundTrip(r)
+}
+
+// annotateEvent decodes a core/v1 or events.k8s.io/v1 Event, adds the
+// operation annotations and encodes it again. It returns false when the
+// body is left unchanged.
+func annotateEvent(contentType string, body []byte) ([]byte, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+	info, ok := runtime.SerializerInfoForMediaType(scheme.Codecs.SupportedMediaTypes(), mediaType)
+	if !ok {
+		return nil, false
+	}
+	obj, gvk, err := info.Serializer.Decode(body, nil, nil)
+	if err != nil {
+		klog.V(5).InfoS("Failed to decode Event, not annotating it", "err", err)
+		return nil, false
+	}
+
+	var meta *metav1.ObjectMeta
+	var ref corev1.ObjectReference
+	switch event := obj.(type) {
+	case *corev1.Event:
+		meta, ref = &event.ObjectMeta, event.InvolvedObject
+	case *eventsv1.Event:
+		meta, ref = &event.ObjectMeta, event.Regarding
+	default:
+		return nil, false
+	}
+	op, ok := latestFor(ref.Kind, ref.Namespace, ref.Name)
+	if !ok {
+		return nil, false
+	}
+	if meta.Annotations == nil {
+		meta.Annotations = map[string]string{}
+	}
+	meta.Annotations[OperationIDAnnotation] = op.ID
+	meta.Annotations[ControllerAnnotation] = op.Controller
+
+	var out bytes.Buffer
+	if err := scheme.Codecs.EncoderForVersion(info.Serializer, gvk.GroupVersion()).Encode(obj, &out); err != nil {
+		klog.V(5).InfoS("Failed to encode Event, not annotating it", "err", err)
+		return nil, false
+	}
+	return out.Bytes(), true
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// roundTripperFunc records the body of the requests it receives.
+type roundTripperFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestAnnotateEvents(t *testing.T) {
+	ctx, end, err := StartForObject(context.Background(), "attacher", "VolumeAttachment", "", "va-events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer end()
+	op, _ := FromContext(ctx)
+
+	coreEvent := func(kind, name string) any {
+		return &corev1.Event{
+			TypeMeta:       metav1.TypeMeta{APIVersion: "v1", Kind: "Event"},
+			ObjectMeta:     metav1.ObjectMeta{Name: "event", Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{Kind: kind, Name: name},
+		}
+	}
+	tests := []struct {
+		name                string
+		method              string
+		path                string
+		event               any
+		expectedAnnotations map[string]string
+	}{
+		{
+			name:   "core event",
+			method: http.MethodPost,
+			path:   "/api/v1/namespaces/default/events",
+			event:  coreEvent("VolumeAttachment", "va-events"),
+			expectedAnnotations: map[string]string{
+				OperationIDAnnotation: op.ID,
+				ControllerAnnotation:  "attacher",
+			},
+		},
+		{
+			name:   "events.k8s.io event",
+			method: http.MethodPost,
+			path:   "/apis/events.k8s.io/v1/namespaces/default/events",
+			event: &eventsv1.Event{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "events.k8s.io/v1", Kind: "Event"},
+				ObjectMeta: metav1.ObjectMeta{Name: "event", Namespace: "default"},
+				Regarding:  corev1.ObjectReference{Kind: "VolumeAttachment", Name: "va-events"},
+			},
+			expectedAnnotations: map[string]string{
+				OperationIDAnnotation: op.ID,
+				ControllerAnnotation:  "attacher",
+			},
+		},
+		{
+			name:   "object without an operation",
+			method: http.MethodPost,
+			path:   "/api/v1/namespaces/default/events",
+			event:  coreEvent("VolumeAttachment", "va-unknown"),
+		},
+		{
+			name:   "update of an event",
+			method: http.MethodPatch,
+			path:   "/api/v1/namespaces/default/events/event",
+			event:  coreEvent("VolumeAttachment", "va-events"),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			body, err := json.Marshal(test.event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var sent []byte
+			rt := AnnotateEvents(roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				sent, err = io.ReadAll(r.Body)
+				if err != nil {
+					return nil, err
+				}
+				if r.ContentLength != int64(len(sent)) {
+					t.Errorf("expected the content length %d, got %d", len(sent), r.ContentLength)
+				}
+				return &http.Response{StatusCode: http.StatusCreated, Body: http.NoBody}, nil
+			}))
+			req, err := http.NewRequest(test.method, "https://127.0.0.1"+test.path, bytes.NewReader(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			if _, err := rt.RoundTrip(req); err != nil {
+				t.Fatal(err)
+			}
+
+			var meta struct {
+				metav1.ObjectMeta `json:"metadata"`
+			}
+			if err := json.Unmarshal(sent, &meta); err != nil {
+				t.Fatal(err)
+			}
+			if len(meta.Annotations) != len(test.expectedAnnotations) {
+				t.Errorf("expected the annotations %v, got %v", test.expectedAnnotations, meta.Annotations)
+			}
+			for key, value := range test.expectedAnnotations {
+				if meta.Annotations[key] != value {
+					t.Errorf("expected the annotation %s to be %q, got %q", key, value, meta.Annotations[key])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operations
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/propagation"
+	"k8s.io/apimachinery/pkg/util/uuid"
+)
+
+// gRPC metadata keys sent to the CSI driver with every request.
+const (
+	OperationIDMetadataKey     = "x-csi-sidecar-operation-id"
+	ControllerMetadataKey      = "x-csi-sidecar-controller"
+	ObjectKindMetadataKey      = "x-csi-sidecar-object-kind"
+	ObjectNamespaceMetadataKey = "x-csi-sidecar-object-namespace"
+	ObjectNameMetadataKey      = "x-csi-sidecar-object-name"
+)
+
+// Propagator is an OpenTelemetry TextMapPropagator that adds the operation
+// of the context to the metadata of outgoing CSI RPCs. The sidecars connect
+// to the driver with connection.WithOtelTracing(), which injects the global
+// propagators into every request.
+//
+// CSI RPCs that aren't part of an operation, e.g. the capability probes at
+// startup, get a new operation ID so that every request can be correlated.
+type Propagator struct{}
+
+var _ propagation.TextMapPropagator = Propagator{}
+
+// Inject implements propagation.TextMapPropagator.
+func (Propagator) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	op, ok := FromContext(ctx)
+	if !ok {
+		// Kubernetes API requests are traced with the same propagators, only
+		// CSI RPCs need an ID of their own.
+		if _, isHTTP := carrier.(propagation.HeaderCarrier); isHTTP {
+			return
+		}
+		op = Operation{ID: string(uuid.NewUUID())}
+	}
+	carrier.Set(OperationIDMetadataKey, op.ID)
+	if op.Controller != "" {
+		carrier.Set(ControllerMetadataKey, op.Controller)
+	}
+	if op.Kind != "" {
+		carrier.Set(ObjectKindMetadataKey, op.Kind)
+		carrier.Set(ObjectNameMetadataKey, op.Name)
+	}
+	if op.Namespace != "" {
+		carrier.Set(ObjectNamespaceMetadataKey, op.Namespace)
+	}
+}
+
+// Extract implements propagation.TextMapPropagator, the sidecars don't
+// receive operations from other processes.
+func (Propagator) Extract(ctx context.Context, _ propagation.TextMapCarrier) context.Context {
+	return ctx
+}
+
+// Fields implements propagation.TextMapPropagator.
+func (Propagator) Fields() []string {
+	return []string{
+		OperationIDMetadataKey,
+		ControllerMetadataKey,
+		ObjectKindMetadataKey,
+		ObjectNamespaceMetadataKey,
+		ObjectNameMetadataKey,
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operations
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel/propagation"
+)
+
+func TestPropagator(t *testing.T) {
+	op := Operation{ID: "id-1", Controller: "provisioner", Kind: "PersistentVolumeClaim", Namespace: "default", Name: "claim-1"}
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		carrier  func() propagation.TextMapCarrier
+		expected map[string]string
+		// expectedNewID is set when an operation ID is generated.
+		expectedNewID bool
+	}{
+		{
+			name:    "operation",
+			ctx:     context.WithValue(context.Background(), contextKey{}, op),
+			carrier: func() propagation.TextMapCarrier { return propagation.MapCarrier{} },
+			expected: map[string]string{
+				OperationIDMetadataKey:     "id-1",
+				ControllerMetadataKey:      "provisioner",
+				ObjectKindMetadataKey:      "PersistentVolumeClaim",
+				ObjectNamespaceMetadataKey: "default",
+				ObjectNameMetadataKey:      "claim-1",
+			},
+		},
+		{
+			name:    "cluster scoped object",
+			ctx:     context.WithValue(context.Background(), contextKey{}, Operation{ID: "id-2", Controller: "attacher", Kind: "VolumeAttachment", Name: "va-1"}),
+			carrier: func() propagation.TextMapCarrier { return propagation.MapCarrier{} },
+			expected: map[string]string{
+				OperationIDMetadataKey: "id-2",
+				ControllerMetadataKey:  "attacher",
+				ObjectKindMetadataKey:  "VolumeAttachment",
+				ObjectNameMetadataKey:  "va-1",
+			},
+		},
+		{
+			name:          "CSI RPC without an operation",
+			ctx:           context.Background(),
+			carrier:       func() propagation.TextMapCarrier { return propagation.MapCarrier{} },
+			expectedNewID: true,
+		},
+		{
+			name:     "Kubernetes API request without an operation",
+			ctx:      context.Background(),
+			carrier:  func() propagation.TextMapCarrier { return propagation.HeaderCarrier(http.Header{}) },
+			expected: map[string]string{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			carrier := test.carrier()
+			Propagator{}.Inject(test.ctx, carrier)
+			if test.expectedNewID {
+				if keys := carrier.Keys(); len(keys) != 1 || carrier.Get(OperationIDMetadataKey) == "" {
+					t.Errorf("expected only a new operation ID, got %v", keys)
+				}
+				return
+			}
+			if keys := carrier.Keys(); len(keys) != len(test.expected) {
+				t.Errorf("expected the metadata %v, got the keys %v", test.expected, keys)
+			}
+			for key, value := range test.expected {
+				if carrier.Get(key) != value {
+					t.Errorf("expected %s to be %q, got %q", key, value, carrier.Get(key))
+				}
+			}
+		})
+	}
+}
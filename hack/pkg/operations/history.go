@@ -0,0 +1,172 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operations
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/utils/lru"
+)
+
+const (
+	// historyVolumes bounds how many volumes the history is kept for.
+	historyVolumes = 1024
+	// historyPerVolume bounds how many RPCs are kept per volume.
+	historyPerVolume = 32
+)
+
+// VolumePathPrefix is where VolumeHistoryHandler is meant to be mounted.
+const VolumePathPrefix = "/debug/volumes/"
+
+// Record is a CSI RPC a sidecar called for a volume.
+type Record struct {
+	Time        time.Time `json:"time"`
+	OperationID string    `json:"operationID,omitempty"`
+	Controller  string    `json:"controller,omitempty"`
+	Object      string    `json:"object,omitempty"`
+	RPC         string    `json:"rpc"`
+	// PersistentVolume is empty when the PV of the volume handle isn't
+	// known, see SetVolumeResolver.
+	PersistentVolume string `json:"persistentVolume,omitempty"`
+	VolumeHandle     string `json:"volumeHandle,omitempty"`
+	Latency          string `json:"latency"`
+	Code             string `json:"code"`
+	Error            string `json:"error,omitempty"`
+	// Retries is the number of failed calls of the same RPC by the same
+	// controller for the volume that directly preceded this one.
+	Retries int `json:"retries"`
+}
+
+// volumeHistory is the ring buffer of the latest records of a volume.
+type volumeHistory struct {
+	mu      sync.Mutex
+	records []Record
+	next    int
+}
+
+func (h *volumeHistory) add(r Record) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if prev, ok := h.latest(r.Controller, r.RPC); ok && prev.Code != "OK" {
+		r.Retries = prev.Retries + 1
+	}
+	if len(h.records) < historyPerVolume {
+		h.records = append(h.records, r)
+		return
+	}
+	h.records[h.next] = r
+	h.next = (h.next + 1) % historyPerVolume
+}
+
+// latest returns the latest record of rpc called by controller, h.mu must
+// be held.
+func (h *volumeHistory) latest(controller, rpc string) (Record, bool) {
+	for i := len(h.records) - 1; i >= 0; i-- {
+		r := h.records[(h.next+i)%len(h.records)]
+		if r.Controller == controller && r.RPC == rpc {
+			return r, true
+		}
+	}
+	return Record{}, false
+}
+
+// list returns the records from the oldest to the latest.
+func (h *volumeHistory) list() []Record {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	records := make([]Record, 0, len(h.records))
+	records = append(records, h.records[h.next:]...)
+	return append(records, h.records[:h.next]...)
+}
+
+// histories maps PV names and volume handles to the history of the volume,
+// both names of a volume share the same history once they were seen
+// together, e.g. in CreateVolume or when the PV of a volume handle is found
+// with the VolumeResolver.
+var (
+	historiesMu sync.Mutex
+	histories   = lru.New(historyVolumes)
+)
+
+// historyFor returns the history of a volume, creating it when needed.
+func historyFor(pvName, handle string) *volumeHistory {
+	historiesMu.Lock()
+	defer historiesMu.Unlock()
+	var h *volumeHistory
+	for _, name := range []string{pvName, handle} {
+		if name == "" {
+			continue
+		}
+		if v, ok := histories.Get(name); ok && h == nil {
+			h = v.(*volumeHistory)
+		}
+	}
+	if h == nil {
+		h = &volumeHistory{}
+	}
+	for _, name := range []string{pvName, handle} {
+		if name != "" {
+			histories.Add(name, h)
+		}
+	}
+	return h
+}
+
+// record adds r to the history of its volume.
+func record(r Record) {
+	if r.PersistentVolume == "" && r.VolumeHandle == "" {
+		return
+	}
+	historyFor(r.PersistentVolume, r.VolumeHandle).add(r)
+}
+
+// VolumeHistory returns the recorded RPCs of a volume, name is either the
+// name of its PersistentVolume or its volume handle.
+func VolumeHistory(name string) ([]Record, bool) {
+	historiesMu.Lock()
+	v, ok := histories.Get(name)
+	historiesMu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	return v.(*volumeHistory).list(), true
+}
+
+// VolumeHistoryHandler serves the history of a volume as JSON at
+// VolumePathPrefix + <PV name or volume handle>.
+func VolumeHistoryHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, VolumePathPrefix)
+		if name == "" || name == r.URL.Path {
+			http.Error(w, "the PersistentVolume name or volume handle is missing", http.StatusBadRequest)
+			return
+		}
+		records, ok := VolumeHistory(name)
+		if !ok {
+			http.Error(w, "no operations recorded for volume "+name, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(records)
+	})
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operations
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVolumeHistoryRingBuffer(t *testing.T) {
+	h := &volumeHistory{}
+	for i := range historyPerVolume + 5 {
+		h.add(Record{Controller: "attacher", RPC: fmt.Sprintf("rpc-%d", i), Code: "OK"})
+	}
+	records := h.list()
+	if len(records) != historyPerVolume {
+		t.Fatalf("expected %d records, got %d", historyPerVolume, len(records))
+	}
+	// The oldest records are overwritten, the others are listed in order.
+	for i, r := range records {
+		if expected := fmt.Sprintf("rpc-%d", i+5); r.RPC != expected {
+			t.Errorf("expected the record %d to be %s, got %s", i, expected, r.RPC)
+		}
+	}
+}
+
+func TestVolumeHistoryRetries(t *testing.T) {
+	tests := []struct {
+		name            string
+		records         []Record
+		expectedRetries []int
+	}{
+		{
+			name: "failures",
+			records: []Record{
+				{Controller: "attacher", RPC: "ControllerPublishVolume", Code: "Unavailable"},
+				{Controller: "attacher", RPC: "ControllerPublishVolume", Code: "DeadlineExceeded"},
+				{Controller: "attacher", RPC: "ControllerPublishVolume", Code: "OK"},
+				{Controller: "attacher", RPC: "ControllerPublishVolume", Code: "Unavailable"},
+			},
+			expectedRetries: []int{0, 1, 2, 0},
+		},
+		{
+			name: "other RPCs and controllers",
+			records: []Record{
+				{Controller: "attacher", RPC: "ControllerPublishVolume", Code: "Unavailable"},
+				{Controller: "attacher", RPC: "ControllerUnpublishVolume", Code: "Unavailable"},
+				{Controller: "resizer", RPC: "ControllerPublishVolume", Code: "Unavailable"},
+				{Controller: "attacher", RPC: "ControllerPublishVolume", Code: "OK"},
+			},
+			expectedRetries: []int{0, 0, 0, 1},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h := &volumeHistory{}
+			for _, r := range test.records {
+				h.add(r)
+			}
+			for i, r := range h.list() {
+				if r.Retries != test.expectedRetries[i] {
+					t.Errorf("expected %d retries for the record %d, got %d", test.expectedRetries[i], i, r.Retries)
+				}
+			}
+		})
+	}
+}
+
+func TestHistoryFor(t *testing.T) {
+	// The names of a volume share its history once seen together.
+	record(Record{PersistentVolume: "pv-history", RPC: "CreateVolume", Code: "OK"})
+	record(Record{PersistentVolume: "pv-history", VolumeHandle: "handle-history", RPC: "CreateVolume", Code: "OK"})
+	record(Record{VolumeHandle: "handle-history", RPC: "ControllerPublishVolume", Code: "OK"})
+	// Records without a volume are dropped.
+	record(Record{RPC: "GetPluginInfo", Code: "OK"})
+
+	for _, name := range []string{"pv-history", "handle-history"} {
+		records, ok := VolumeHistory(name)
+		if !ok {
+			t.Errorf("expected the history of %s", name)
+			continue
+		}
+		if len(records) != 3 {
+			t.Errorf("expected 3 records for %s, got %+v", name, records)
+		}
+	}
+
+	// The least recently used volumes are forgotten.
+	for i := range historyVolumes {
+		record(Record{PersistentVolume: fmt.Sprintf("pv-%d", i), RPC: "DeleteVolume", Code: "OK"})
+	}
+	if _, ok := VolumeHistory("pv-history"); ok {
+		t.Error("expected the history of pv-history to be forgotten")
+	}
+	if _, ok := VolumeHistory(fmt.Sprintf("pv-%d", historyVolumes-1)); !ok {
+		t.Error("expected the history of the latest volume")
+	}
+}
+
+func TestVolumeHistoryHandler(t *testing.T) {
+	record(Record{PersistentVolume: "pv-handler", RPC: "CreateVolume", Code: "OK"})
+
+	tests := []struct {
+		path           string
+		expectedStatus int
+	}{
+		{path: VolumePathPrefix + "pv-handler", expectedStatus: http.StatusOK},
+		{path: VolumePathPrefix + "pv-unknown", expectedStatus: http.StatusNotFound},
+		{path: VolumePathPrefix, expectedStatus: http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		VolumeHistoryHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, test.path, nil))
+		if rec.Code != test.expectedStatus {
+			t.Errorf("%s: expected status %d, got %d", test.path, test.expectedStatus, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package operations tracks the operations the CSI sidecars run for a
+// Kubernetes object, e.g. the processing of a VolumeAttachment dequeued by
+// the attacher.
+//
+// Every operation gets a generated ID that is added to the klog logger of
+// its context, sent to the CSI driver as gRPC metadata together with the
+// object reference (see Propagator) and added as annotations to the Events
+// emitted for the object (see AnnotateEvents).
+package operations
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/lru"
+
+	"github.com/kubernetes-csi/csi-sidecars/pkg/admin"
+	"github.com/kubernetes-csi/csi-sidecars/pkg/queues"
+	"github.com/kubernetes-csi/csi-sidecars/pkg/tracing"
+)
+
+// recentOperationsSize bounds how many objects AnnotateEvents remembers the
+// latest operation of.
+const recentOperationsSize = 4096
+
+// Delays after which an object that couldn't be processed right away is
+// dequeued again.
+const (
+	// pausedRequeueDelay is how long an object whose processing is paused
+	// waits in its queue before the pause is checked again.
+	pausedRequeueDelay = 5 * time.Second
+	// workerLimitRequeueDelay is how long an object waits in its queue while
+	// its controller has no worker left, see admin.SetWorkerLimit.
+	workerLimitRequeueDelay = time.Second
+)
+
+const (
+	operationIDKey = attribute.Key("csi.sidecar.operation_id")
+)
+
+// Operation is a unit of work a sidecar controller runs for an object.
+type Operation struct {
+	ID         string
+	Controller string
+	Kind       string
+	Namespace  string
+	Name       string
+}
+
+// ObjectRef returns the reference of the object of the operation, e.g.
+// PersistentVolumeClaim/default/my-claim.
+func (op Operation) ObjectRef() string {
+	if op.Kind == "" {
+		return ""
+	}
+	if op.Namespace == "" {
+		return op.Kind + "/" + op.Name
+	}
+	return op.Kind + "/" + op.Namespace + "/" + op.Name
+}
+
+type contextKey struct{}
+
+// FromContext returns the operation of ctx.
+func FromContext(ctx context.Context) (Operation, bool) {
+	op, ok := ctx.Value(contextKey{}).(Operation)
+	return op, ok
+}
+
+// recent maps an object reference to the latest operation started for it.
+var recent = lru.New(recentOperationsSize)
+
+// latestFor returns the latest operation started for an object.
+func latestFor(kind, namespace, name string) (Operation, bool) {
+	op, ok := recent.Get(Operation{Kind: kind, Namespace: namespace, Name: name}.ObjectRef())
+	if !ok {
+		return Operation{}, false
+	}
+	return op.(Operation), true
+}
+
+// StartForObject starts an operation of controller for an object. The
+// returned context carries the operation, a logger with the operation ID and
+// object reference, and the span of the operation. The returned function
+// ends the operation.
+//
+// While the processing of the object is paused through the admin API, or
+// while the controller processes as many objects as its worker limit allows,
+// no operation is started and an error is returned instead, the caller
+// returns it so that the object is retried later.
+func StartForObject(ctx context.Context, controller, kind, namespace, name string) (context.Context, func(), error) {
+	release, _, err := admit(controller, kind, namespace, name)
+	if err != nil {
+		return ctx, func() {}, err
+	}
+	ctx, span := tracing.StartSpan(ctx, fmt.Sprintf("%s %s", controller, kind))
+	ctx, end := start(ctx, span, controller, kind, namespace, name, release)
+	return ctx, end, nil
+}
+
+// Start starts the operation of an item a controller dequeued from queue,
+// queue is the name of the queue field in the controller, e.g. vaQueue, and
+// tells which kind of object key identifies. Its span is the one of the
+// dequeued item. do_sync.sh inserts the call in the sidecar controllers.
+//
+// While the processing of the object is paused through the admin API, or
+// while the controller processes as many objects as its worker limit allows,
+// no operation is started and false is returned, the item is added to the
+// queue again after a delay and the caller returns right away. The items of
+// queues that aren't registered can't be added back and are always
+// processed.
+func Start(ctx context.Context, controller, queue string, key any) (context.Context, func(), bool) {
+	kind, namespace, name := objectFromQueue(queue, fmt.Sprint(key))
+	release, retryAfter, err := admit(controller, kind, namespace, name)
+	if err != nil {
+		if requeue(ctx, controller, queue, fmt.Sprint(key), retryAfter, err) {
+			return ctx, func() {}, false
+		}
+		release = func() {}
+	}
+	ctx, span := tracing.StartDequeueSpan(ctx, queue, key)
+	ctx, end := start(ctx, span, controller, kind, namespace, name, release)
+	return ctx, end, true
+}
+
+// admit checks whether controller can process an object right away. It
+// returns the function releasing the worker of the object, or why the object
+// can't be processed and after which delay to try again.
+func admit(controller, kind, namespace, name string) (func(), time.Duration, error) {
+	if scope, paused := admin.PausedFor(controller, kind, namespace, name); paused {
+		ref := Operation{Kind: kind, Namespace: namespace, Name: name}.ObjectRef()
+		return nil, pausedRequeueDelay, fmt.Errorf("the processing of %s by the %s controller is paused (%s)", ref, controller, scope)
+	}
+	release, ok := admin.TryAcquireWorker(controller)
+	if !ok {
+		return nil, workerLimitRequeueDelay, fmt.Errorf("the %s controller processes as many objects as its worker limit allows", controller)
+	}
+	return release, 0, nil
+}
+
+// requeue adds an object that can't be processed right away back to its
+// queue after delay and returns true, reason is why it can't be processed.
+func requeue(ctx context.Context, controller, queue, key string, delay time.Duration, reason error) bool {
+	logger := klog.FromContext(ctx)
+	q, ok := queues.Lookup(controller, queue)
+	if !ok {
+		logger.V(2).Info("Queue isn't registered, processing anyway", "controller", controller, "queue", queue, "key", key, "reason", reason)
+		return false
+	}
+	if err := q.EnqueueAfter(key, delay); err != nil {
+		logger.Error(err, "Failed to requeue an object, processing anyway", "controller", controller, "queue", queue, "key", key, "reason", reason)
+		return false
+	}
+	logger.V(4).Info("Requeued an object", "controller", controller, "queue", queue, "key", key, "delay", delay, "reason", reason)
+	return true
+}
+
+// start starts an operation for an object, span is the span of the operation
+// and release releases the worker of the object.
+func start(ctx context.Context, span trace.Span, controller, kind, namespace, name string, release func()) (context.Context, func()) {
+	op := Operation{
+		ID:         string(uuid.NewUUID()),
+		Controller: controller,
+		Kind:       kind,
+		Namespace:  namespace,
+		Name:       name,
+	}
+	recent.Add(op.ObjectRef(), op)
+	stopRunning := setRunning(op)
+
+	ctx = context.WithValue(ctx, contextKey{}, op)
+	objectKey := kind
+	if objectKey == "" {
+		objectKey = "object"
+	}
+	logger := klog.LoggerWithValues(klog.FromContext(ctx), "operationID", op.ID, "controller", controller, objectKey, klog.KRef(namespace, name))
+	ctx = klog.NewContext(ctx, logger)
+
+	span.SetAttributes(operationIDKey.String(op.ID), tracing.ControllerKey.String(controller))
+	span.SetAttributes(tracing.ObjectAttributes(kind, namespace, name)...)
+	return ctx, func() {
+		stopRunning()
+		span.End()
+		release()
+	}
+}
+
+// Track is Start for the sync functions that don't receive a context. The
+// operation is recorded and its Events annotated, and the CSI RPCs made while
+// it runs find it through the volume they're made for, see RecordRPCs.
+func Track(controller, queue string, key any) (func(), bool) {
+	_, end, ok := Start(context.Background(), controller, queue, key)
+	return end, ok
+}
+
+// objectFromQueue maps the key of the known sidecar queues to the object
+// they hold.
+func objectFromQueue(queue, key string) (kind, namespace, name string) {
+	kind = queues.KindForQueue(queue)
+	if ns, n, ok := strings.Cut(key, "/"); ok {
+		return kind, ns, n
+	}
+	return kind, "", key
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operations
+
+import (
+	"context"
+	"path"
+	"sync/atomic"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/kubernetes-csi/csi-lib-utils/connection"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+)
+
+// RecordRPCs returns the connection option that adds the CSI RPCs a
+// controller makes for a volume to the history of the volume, e.g.
+//
+//	connection.Connect(ctx, address, metricsManager, operations.RecordRPCs("attacher"))
+//
+// RPCs made without the context of an operation get the operation the
+// controller runs for their volume, see SetVolumeResolver. The interceptor
+// runs before the stats handler of connection.WithOtelTracing() injects the
+// operation into the gRPC metadata, so the driver receives it too.
+//
+// do_sync.sh adds it next to connection.WithOtelTracing() in the sidecars.
+func RecordRPCs(controller string) connection.Option {
+	return connection.ExtraDialOptions(grpc.WithChainUnaryInterceptor(
+		func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			return recordRPC(ctx, controller, method, req, reply, cc, invoker, opts...)
+		},
+	))
+}
+
+var rpcTimeout atomic.Int64
+
+// SetRPCTimeout bounds how long the CSI RPCs made with RecordRPCs take, 0
+// removes the bound. The sidecars set the timeouts of their RPCs themselves,
+// it can only shorten them, e.g. when the timeout is lowered by a reload of
+// the configuration.
+func SetRPCTimeout(timeout time.Duration) {
+	rpcTimeout.Store(int64(timeout))
+}
+
+// recordRPC is a grpc.UnaryClientInterceptor of controller.
+func recordRPC(ctx context.Context, controller, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	op, ok := FromContext(ctx)
+	if !ok {
+		_, objects := resolveVolume(volumeHandleOf(req))
+		if op, ok = runningFor(controller, objects); ok {
+			ctx = context.WithValue(ctx, contextKey{}, op)
+			ctx = klog.NewContext(ctx, klog.LoggerWithValues(klog.FromContext(ctx), "operationID", op.ID, "controller", op.Controller, "object", op.ObjectRef()))
+		}
+	}
+	if ok {
+		klog.FromContext(ctx).V(5).Info("Calling the CSI driver", "method", method)
+	}
+	if timeout := time.Duration(rpcTimeout.Load()); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+
+	pvName, handle := volumeOf(req, reply)
+	if pvName == "" && handle == "" {
+		return err
+	}
+	if pvName == "" && op.Kind == "PersistentVolume" {
+		pvName = op.Name
+	}
+	if pvName == "" {
+		// e.g. the attacher only knows the volume handle.
+		pvName, _ = resolveVolume(handle)
+	}
+	r := Record{
+		Time:             start,
+		OperationID:      op.ID,
+		Controller:       op.Controller,
+		Object:           op.ObjectRef(),
+		RPC:              path.Base(method),
+		PersistentVolume: pvName,
+		VolumeHandle:     handle,
+		Latency:          time.Since(start).String(),
+		Code:             status.Code(err).String(),
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	record(r)
+	return err
+}
+
+// volumeOf returns the PV name and volume handle of a CSI request. The
+// external-provisioner names volumes after their PV, so CreateVolume links
+// both.
+func volumeOf(req, reply any) (pvName, handle string) {
+	if create, ok := req.(*csi.CreateVolumeRequest); ok {
+		if resp, ok := reply.(*csi.CreateVolumeResponse); ok {
+			handle = resp.GetVolume().GetVolumeId()
+		}
+		return create.GetName(), handle
+	}
+	return "", volumeHandleOf(req)
+}
+
+// volumeHandleOf returns the volume handle of a CSI request, if any.
+func volumeHandleOf(req any) string {
+	if r, ok := req.(interface{ GetVolumeId() string }); ok {
+		return r.GetVolumeId()
+	}
+	return ""
+}
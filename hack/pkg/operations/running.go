@@ -0,0 +1,97 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operations
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Object is a Kubernetes object the sidecars process.
+type Object struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// VolumeResolver returns the name of the PersistentVolume of a CSI volume
+// handle and the objects the sidecars queue for the volume: the PV, its PVC
+// and its VolumeAttachments. The name is empty when the volume isn't known.
+type VolumeResolver func(handle string) (pvName string, objects []Object)
+
+var volumeResolver atomic.Pointer[VolumeResolver]
+
+// SetVolumeResolver sets how the objects of a CSI volume are found. The CSI
+// RPCs made without the context of an operation, e.g. by the sync functions
+// that don't receive one (see Track), find the running operation of their
+// volume with it.
+func SetVolumeResolver(resolver VolumeResolver) {
+	volumeResolver.Store(&resolver)
+}
+
+// resolveVolume returns the PV name and objects of a volume handle.
+func resolveVolume(handle string) (string, []Object) {
+	resolver := volumeResolver.Load()
+	if resolver == nil || handle == "" {
+		return "", nil
+	}
+	return (*resolver)(handle)
+}
+
+type runningKey struct {
+	controller string
+	object     string
+}
+
+// running maps a controller and an object reference to the operation the
+// controller runs for the object. A workqueue doesn't hand out the same key
+// to two workers, so there is at most one.
+var (
+	runningMu sync.Mutex
+	running   = map[runningKey]Operation{}
+)
+
+// setRunning records op as running until the returned function is called.
+func setRunning(op Operation) func() {
+	if op.Kind == "" {
+		return func() {}
+	}
+	key := runningKey{controller: op.Controller, object: op.ObjectRef()}
+	runningMu.Lock()
+	running[key] = op
+	runningMu.Unlock()
+	return func() {
+		runningMu.Lock()
+		defer runningMu.Unlock()
+		if running[key].ID == op.ID {
+			delete(running, key)
+		}
+	}
+}
+
+// runningFor returns the operation controller runs for one of objects.
+func runningFor(controller string, objects []Object) (Operation, bool) {
+	runningMu.Lock()
+	defer runningMu.Unlock()
+	for _, obj := range objects {
+		ref := Operation{Kind: obj.Kind, Namespace: obj.Namespace, Name: obj.Name}.ObjectRef()
+		if op, ok := running[runningKey{controller: controller, object: ref}]; ok {
+			return op, true
+		}
+	}
+	return Operation{}, false
+}
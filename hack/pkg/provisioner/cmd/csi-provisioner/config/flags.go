@@ -0,0 +1,96 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+)
+
+type ProvisionerConfiguration struct {
+	KubeAPICapacityQPS             float64
+	KubeAPICapacityBurst           int
+	VolumeNamePrefix               string
+	VolumeNameUUIDLength           int
+	FinalizerThreads               uint
+	CapacityThreads                uint
+	StrictTopology                 bool
+	ImmediateTopology              bool
+	ExtraCreateMetadata            bool
+	EnableProfile                  bool
+	EnableCapacity                 bool
+	CapacityImmediateBinding       bool
+	CapacityPollInterval           time.Duration
+	CapacityOwnerrefLevel          int
+	EnableNodeDeployment           bool
+	NodeDeploymentImmediateBinding bool
+	NodeDeploymentBaseDelay        time.Duration
+	NodeDeploymentMaxDelay         time.Duration
+	ControllerPublishReadOnly      bool
+	PreventVolumeModeConversion    bool
+
+	// prefix of the flags, see Validate.
+	prefix string
+}
+
+func registerProvisionerFlags(flags *flag.FlagSet, configuration *ProvisionerConfiguration, prefix string) {
+	configuration.prefix = prefix
+	flags.Float64Var(&configuration.KubeAPICapacityQPS, prefix+"kube-api-capacity-qps", 1, "QPS to use for storage capacity updates while communicating with the kubernetes apiserver. Defaults to 1.0.")
+	flags.IntVar(&configuration.KubeAPICapacityBurst, prefix+"kube-api-capacity-burst", 5, "Burst to use for storage capacity updates while communicating with the kubernetes apiserver. Defaults to 5.")
+	flags.StringVar(&configuration.VolumeNamePrefix, prefix+"volume-name-prefix", "pvc", "Prefix to apply to the name of a created volume.")
+	flags.IntVar(&configuration.VolumeNameUUIDLength, prefix+"volume-name-uuid-length", -1, "Truncates generated UUID of a created volume to this length. Defaults behavior is to NOT truncate.")
+	flags.UintVar(&configuration.FinalizerThreads, prefix+"cloning-protection-threads", 1, "Number of simultaneously running threads, handling cloning finalizer removal")
+	flags.UintVar(&configuration.CapacityThreads, prefix+"capacity-threads", 1, "Number of simultaneously running threads, handling CSIStorageCapacity objects")
+	flags.BoolVar(&configuration.StrictTopology, prefix+"strict-topology", false, "Late binding: pass only selected node topology to CreateVolume Request, unlike default behavior of passing aggregated cluster topologies that match with topology keys of the selected node.")
+	flags.BoolVar(&configuration.ImmediateTopology, prefix+"immediate-topology", true, "Immediate binding: pass aggregated cluster topologies for all nodes where the CSI driver is available (enabled, the default) or no topology requirements (if disabled).")
+	flags.BoolVar(&configuration.ExtraCreateMetadata, prefix+"extra-create-metadata", false, "If set, add pv/pvc metadata to plugin create requests as parameters.")
+	flags.BoolVar(&configuration.EnableProfile, prefix+"enable-pprof", false, "Enable pprof profiling on the TCP network address specified by --http-endpoint. The HTTP path is `/debug/pprof/`.")
+	flags.BoolVar(&configuration.EnableCapacity, prefix+"enable-capacity", false, "This enables producing CSIStorageCapacity objects with capacity information from the driver's GetCapacity call.")
+	flags.BoolVar(&configuration.CapacityImmediateBinding, prefix+"capacity-for-immediate-binding", false, "Enables producing capacity information for storage classes with immediate binding. Not needed for the Kubernetes scheduler, maybe useful for other consumers or for debugging.")
+	flags.DurationVar(&configuration.CapacityPollInterval, prefix+"capacity-poll-interval", time.Minute, "How long the external-provisioner waits before checking for storage capacity changes.")
+	flags.IntVar(&configuration.CapacityOwnerrefLevel, prefix+"capacity-ownerref-level", 1, "The level indicates the number of objects that need to be traversed starting from the pod identified by the POD_NAME and NAMESPACE environment variables to reach the owning object for CSIStorageCapacity objects: -1 for no owner, 0 for the pod itself, 1 for a StatefulSet or DaemonSet, 2 for a Deployment, etc.")
+	flags.BoolVar(&configuration.EnableNodeDeployment, prefix+"node-deployment", false, "Enables deploying the external-provisioner together with a CSI driver on nodes to manage node-local volumes.")
+	flags.BoolVar(&configuration.NodeDeploymentImmediateBinding, prefix+"node-deployment-immediate-binding", true, "Determines whether immediate binding is supported when deployed on each node.")
+	flags.DurationVar(&configuration.NodeDeploymentBaseDelay, prefix+"node-deployment-base-delay", 20*time.Second, "Determines how long the external-provisioner sleeps initially before trying to own a PVC with immediate binding.")
+	flags.DurationVar(&configuration.NodeDeploymentMaxDelay, prefix+"node-deployment-max-delay", 60*time.Second, "Determines how long the external-provisioner sleeps at most before trying to own a PVC with immediate binding.")
+	flags.BoolVar(&configuration.ControllerPublishReadOnly, prefix+"controller-publish-readonly", false, "This option enables PV to be marked as readonly at controller publish volume call if PVC accessmode has been set to ROX.")
+	flags.BoolVar(&configuration.PreventVolumeModeConversion, prefix+"prevent-volume-mode-conversion", true, "Prevents an unauthorised user from modifying the volume mode when creating a PVC from an existing VolumeSnapshot.")
+}
+
+func RegisterProvisionerFlags(flags *flag.FlagSet, configuration *ProvisionerConfiguration) {
+	registerProvisionerFlags(flags, configuration, "")
+}
+
+func RegisterProvisionerFlagsWithPrefix(flags *flag.FlagSet, configuration *ProvisionerConfiguration) {
+	registerProvisionerFlags(flags, configuration, "provisioner-")
+}
+
+// Validate returns every invalid flag value of the configuration.
+func (c *ProvisionerConfiguration) Validate() error {
+	var errs []error
+	if c.KubeAPICapacityQPS < 0 {
+		errs = append(errs, fmt.Errorf("--%skube-api-capacity-qps must not be negative", c.prefix))
+	}
+	if c.KubeAPICapacityBurst < 0 {
+		errs = append(errs, fmt.Errorf("--%skube-api-capacity-burst must not be negative", c.prefix))
+	}
+	if c.CapacityOwnerrefLevel < -1 {
+		errs = append(errs, fmt.Errorf("--%scapacity-ownerref-level must be -1 or greater", c.prefix))
+	}
+	for _, d := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"capacity-poll-interval", c.CapacityPollInterval},
+		{"node-deployment-base-delay", c.NodeDeploymentBaseDelay},
+		{"node-deployment-max-delay", c.NodeDeploymentMaxDelay},
+	} {
+		if d.value < 0 {
+			errs = append(errs, fmt.Errorf("--%s%s must not be negative", c.prefix, d.name))
+		}
+	}
+	if c.EnableNodeDeployment && c.NodeDeploymentBaseDelay > c.NodeDeploymentMaxDelay {
+		errs = append(errs, fmt.Errorf("--%snode-deployment-base-delay must not be greater than --%snode-deployment-max-delay", c.prefix, c.prefix))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,182 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegisterProvisionerFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func(*flag.FlagSet, *ProvisionerConfiguration)
+		prefix   string
+	}{
+		{
+			name:     "unprefixed",
+			register: RegisterProvisionerFlags,
+		},
+		{
+			name:     "prefixed",
+			register: RegisterProvisionerFlagsWithPrefix,
+			prefix:   "provisioner-",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			var c ProvisionerConfiguration
+			test.register(flags, &c)
+
+			defaults := ProvisionerConfiguration{
+				KubeAPICapacityQPS:             1,
+				KubeAPICapacityBurst:           5,
+				VolumeNamePrefix:               "pvc",
+				VolumeNameUUIDLength:           -1,
+				FinalizerThreads:               1,
+				CapacityThreads:                1,
+				ImmediateTopology:              true,
+				CapacityPollInterval:           time.Minute,
+				CapacityOwnerrefLevel:          1,
+				NodeDeploymentImmediateBinding: true,
+				NodeDeploymentBaseDelay:        20 * time.Second,
+				NodeDeploymentMaxDelay:         60 * time.Second,
+				PreventVolumeModeConversion:    true,
+				prefix:                         test.prefix,
+			}
+			if c != defaults {
+				t.Errorf("expected the defaults %+v, got %+v", defaults, c)
+			}
+
+			args := []string{
+				"kube-api-capacity-qps=2.5",
+				"kube-api-capacity-burst=10",
+				"volume-name-prefix=pv",
+				"volume-name-uuid-length=8",
+				"cloning-protection-threads=2",
+				"capacity-threads=3",
+				"strict-topology",
+				"immediate-topology=false",
+				"extra-create-metadata",
+				"enable-pprof",
+				"enable-capacity",
+				"capacity-for-immediate-binding",
+				"capacity-poll-interval=2m",
+				"capacity-ownerref-level=2",
+				"node-deployment",
+				"node-deployment-immediate-binding=false",
+				"node-deployment-base-delay=10s",
+				"node-deployment-max-delay=30s",
+				"controller-publish-readonly",
+				"prevent-volume-mode-conversion=false",
+			}
+			for i := range args {
+				args[i] = "--" + test.prefix + args[i]
+			}
+			if err := flags.Parse(args); err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			expected := ProvisionerConfiguration{
+				KubeAPICapacityQPS:             2.5,
+				KubeAPICapacityBurst:           10,
+				VolumeNamePrefix:               "pv",
+				VolumeNameUUIDLength:           8,
+				FinalizerThreads:               2,
+				CapacityThreads:                3,
+				StrictTopology:                 true,
+				ImmediateTopology:              false,
+				ExtraCreateMetadata:            true,
+				EnableProfile:                  true,
+				EnableCapacity:                 true,
+				CapacityImmediateBinding:       true,
+				CapacityPollInterval:           2 * time.Minute,
+				CapacityOwnerrefLevel:          2,
+				EnableNodeDeployment:           true,
+				NodeDeploymentImmediateBinding: false,
+				NodeDeploymentBaseDelay:        10 * time.Second,
+				NodeDeploymentMaxDelay:         30 * time.Second,
+				ControllerPublishReadOnly:      true,
+				PreventVolumeModeConversion:    false,
+				prefix:                         test.prefix,
+			}
+			if c != expected {
+				t.Errorf("expected %+v, got %+v", expected, c)
+			}
+		})
+	}
+}
+
+func TestRegisterProvisionerFlagsRejectsOtherPrefix(t *testing.T) {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	var c ProvisionerConfiguration
+	RegisterProvisionerFlagsWithPrefix(flags, &c)
+	if err := flags.Parse([]string{"--volume-name-prefix=pv"}); err == nil {
+		t.Error("expected the unprefixed flag to be unknown")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name           string
+		args           []string
+		expectedErrors []string
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name: "invalid values",
+			args: []string{
+				"--provisioner-kube-api-capacity-qps=-1",
+				"--provisioner-capacity-ownerref-level=-2",
+				"--provisioner-capacity-poll-interval=-1s",
+			},
+			expectedErrors: []string{
+				"--provisioner-kube-api-capacity-qps must not be negative",
+				"--provisioner-capacity-ownerref-level must be -1 or greater",
+				"--provisioner-capacity-poll-interval must not be negative",
+			},
+		},
+		{
+			name: "node deployment delays",
+			args: []string{
+				"--provisioner-node-deployment",
+				"--provisioner-node-deployment-base-delay=2m",
+				"--provisioner-node-deployment-max-delay=1m",
+			},
+			expectedErrors: []string{
+				"--provisioner-node-deployment-base-delay must not be greater than --provisioner-node-deployment-max-delay",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			var c ProvisionerConfiguration
+			RegisterProvisionerFlagsWithPrefix(flags, &c)
+			if err := flags.Parse(test.args); err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			err := c.Validate()
+			if len(test.expectedErrors) == 0 {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected errors %q, got none", test.expectedErrors)
+			}
+			for _, expected := range test.expectedErrors {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("expected %q in the error, got %v", expected, err)
+				}
+			}
+		})
+	}
+}
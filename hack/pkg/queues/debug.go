@@ -0,0 +1,276 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queues
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DebugPath is where Handler is meant to be mounted.
+const DebugPath = "/debug/queues"
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+// States of an item.
+const (
+	StateWaiting    = "waiting"
+	StateDelayed    = "delayed"
+	StateProcessing = "processing"
+	// StateRetrying is the state of the items a rate limiter retries, see
+	// RateLimiterQueue.
+	StateRetrying = "retrying"
+)
+
+// Item is an item of a registered queue.
+type Item struct {
+	Controller string `json:"controller"`
+	Queue      string `json:"queue"`
+	Key        string `json:"key"`
+	State      string `json:"state"`
+	// Since is when the item entered its state.
+	Since   time.Time `json:"since"`
+	Waiting string    `json:"waiting"`
+	// ReadyAt is when a delayed item is handed to the workers.
+	ReadyAt *time.Time `json:"readyAt,omitempty"`
+	// AddedWhileProcessing is set when the item is processed again once done.
+	AddedWhileProcessing bool `json:"addedWhileProcessing,omitempty"`
+	Requeues             int  `json:"requeues"`
+	// Backoff is the delay of the latest rate limited requeue, computed from
+	// the retry intervals of the controller, see SetBackoff.
+	Backoff string `json:"backoff,omitempty"`
+}
+
+// QueueSummary describes a registered queue.
+type QueueSummary struct {
+	Controller string `json:"controller"`
+	Queue      string `json:"queue"`
+	Kind       string `json:"kind,omitempty"`
+	// Depth is the number of items ready to be processed.
+	Depth      int `json:"depth"`
+	Delayed    int `json:"delayed"`
+	Processing int `json:"processing"`
+	// Requeues is the number of rate limited requeues of the queue, it's only
+	// known for the queues created inside a library, see
+	// RegisterLibraryQueue.
+	Requeues int `json:"requeues,omitempty"`
+}
+
+// Page is the response of Handler.
+type Page struct {
+	Queues []QueueSummary `json:"queues"`
+	Items  []Item         `json:"items"`
+	// Continue is passed as ?continue= to get the next page of items, it's
+	// empty on the last page.
+	Continue string `json:"continue,omitempty"`
+	// Unregistered maps the controllers whose queues can't be listed to the
+	// reason, see MarkUnregistered.
+	Unregistered map[string]string `json:"unregistered,omitempty"`
+}
+
+type itemState struct {
+	processing bool
+	since      time.Time
+	readyAt    time.Time
+
+	// Set when the item is added while it's processed.
+	dirty        bool
+	dirtySince   time.Time
+	dirtyReadyAt time.Time
+}
+
+// markAdded records that the item was added at now and is ready at readyAt,
+// the workqueue keeps the earliest time when an item is added repeatedly.
+func (s *itemState) markAdded(now, readyAt time.Time) {
+	if s.processing {
+		if !s.dirty {
+			s.dirty, s.dirtySince, s.dirtyReadyAt = true, now, readyAt
+		} else if readyAt.Before(s.dirtyReadyAt) {
+			s.dirtyReadyAt = readyAt
+		}
+		return
+	}
+	if s.since.IsZero() {
+		s.since, s.readyAt = now, readyAt
+	} else if readyAt.Before(s.readyAt) {
+		s.readyAt = readyAt
+	}
+}
+
+func (s *itemState) startProcessing(now time.Time) {
+	s.processing, s.since, s.readyAt = true, now, time.Time{}
+	s.dirty = false
+}
+
+// doneProcessing returns whether the item is still queued.
+func (s *itemState) doneProcessing() bool {
+	if !s.dirty {
+		return false
+	}
+	s.processing, s.since, s.readyAt = false, s.dirtySince, s.dirtyReadyAt
+	s.dirty = false
+	return true
+}
+
+func (s *itemState) item(now time.Time) Item {
+	item := Item{Since: s.since, Waiting: now.Sub(s.since).Round(time.Millisecond).String()}
+	switch {
+	case s.processing:
+		item.State = StateProcessing
+		item.AddedWhileProcessing = s.dirty
+	case s.readyAt.After(now):
+		item.State = StateDelayed
+		readyAt := s.readyAt
+		item.ReadyAt = &readyAt
+	default:
+		item.State = StateWaiting
+	}
+	return item
+}
+
+type backoffParams struct {
+	base, max time.Duration
+}
+
+var (
+	backoffMu sync.RWMutex
+	backoffs  = map[string]backoffParams{}
+)
+
+// SetBackoff sets the parameters of the exponential failure rate limiters
+// of controller, e.g. --retry-interval-start and --retry-interval-max. The
+// parameters set for the empty controller apply to the other controllers.
+func SetBackoff(controller string, base, maxDelay time.Duration) {
+	backoffMu.Lock()
+	defer backoffMu.Unlock()
+	backoffs[controller] = backoffParams{base: base, max: maxDelay}
+}
+
+// backoffFor returns the delay of the requeues-th rate limited requeue of
+// controller, like workqueue.TypedItemExponentialFailureRateLimiter computes it.
+func backoffFor(controller string, requeues int) time.Duration {
+	backoffMu.RLock()
+	params, ok := backoffs[controller]
+	if !ok {
+		params = backoffs[""]
+	}
+	backoffMu.RUnlock()
+	if requeues <= 0 || params.base <= 0 {
+		return 0
+	}
+	backoff := params.base
+	for i := 1; i < requeues; i++ {
+		backoff *= 2
+		if params.max > 0 && backoff >= params.max {
+			return params.max
+		}
+	}
+	if params.max > 0 && backoff > params.max {
+		return params.max
+	}
+	return backoff
+}
+
+// Handler serves the registered queues and their items as JSON, followed by
+// the library queues and the items their rate limiters retry. The items are
+// paginated with ?limit= and ?continue=, and can be filtered with
+// ?controller= and ?queue=.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		limit := defaultLimit
+		if v := query.Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				http.Error(w, fmt.Sprintf("invalid limit %q", v), http.StatusBadRequest)
+				return
+			}
+			limit = min(n, maxLimit)
+		}
+		offset := 0
+		if v := query.Get("continue"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				http.Error(w, fmt.Sprintf("invalid continue %q", v), http.StatusBadRequest)
+				return
+			}
+			offset = n
+		}
+
+		now := time.Now()
+		page := Page{Queues: []QueueSummary{}, Items: []Item{}}
+		matches := func(controller, queue string) bool {
+			if c := query.Get("controller"); c != "" && c != controller {
+				return false
+			}
+			if n := query.Get("queue"); n != "" && n != queue {
+				return false
+			}
+			return true
+		}
+		var items []Item
+		for _, q := range List() {
+			if !matches(q.Controller(), q.Name()) {
+				continue
+			}
+			queueItems := q.snapshot(now)
+			summary := QueueSummary{Controller: q.Controller(), Queue: q.Name(), Kind: q.Kind(), Depth: q.Len()}
+			for _, item := range queueItems {
+				switch item.State {
+				case StateDelayed:
+					summary.Delayed++
+				case StateProcessing:
+					summary.Processing++
+				}
+			}
+			page.Queues = append(page.Queues, summary)
+			items = append(items, queueItems...)
+		}
+		page.Queues = append(page.Queues, librarySummaries(matches)...)
+		items = append(items, libraryItems(now, matches)...)
+		mu.RLock()
+		for controller, reason := range unregistered {
+			if c := query.Get("controller"); c != "" && c != controller {
+				continue
+			}
+			if page.Unregistered == nil {
+				page.Unregistered = map[string]string{}
+			}
+			page.Unregistered[controller] = reason
+		}
+		mu.RUnlock()
+		if offset < len(items) {
+			end := min(offset+limit, len(items))
+			page.Items = items[offset:end]
+			if end < len(items) {
+				page.Continue = strconv.Itoa(end)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(page)
+	})
+}
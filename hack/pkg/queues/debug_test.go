@@ -0,0 +1,270 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queues
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+)
+
+func TestBackoffFor(t *testing.T) {
+	SetBackoff("", time.Second, time.Minute)
+	SetBackoff("attacher", time.Second, 10*time.Second)
+	t.Cleanup(func() {
+		backoffMu.Lock()
+		delete(backoffs, "")
+		delete(backoffs, "attacher")
+		backoffMu.Unlock()
+	})
+
+	tests := []struct {
+		controller string
+		requeues   int
+		expected   time.Duration
+	}{
+		{controller: "attacher", requeues: 0, expected: 0},
+		{controller: "attacher", requeues: 1, expected: time.Second},
+		{controller: "attacher", requeues: 2, expected: 2 * time.Second},
+		{controller: "attacher", requeues: 4, expected: 8 * time.Second},
+		{controller: "attacher", requeues: 5, expected: 10 * time.Second},
+		{controller: "attacher", requeues: 100, expected: 10 * time.Second},
+		// The controllers without their own parameters use those of "".
+		{controller: "resizer", requeues: 5, expected: 16 * time.Second},
+		{controller: "resizer", requeues: 7, expected: time.Minute},
+	}
+	for _, test := range tests {
+		if backoff := backoffFor(test.controller, test.requeues); backoff != test.expected {
+			t.Errorf("%s, %d requeues: expected the backoff %s, got %s", test.controller, test.requeues, test.expected, backoff)
+		}
+	}
+}
+
+func TestItemState(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time {
+		return start.Add(time.Duration(seconds) * time.Second)
+	}
+
+	tests := []struct {
+		name string
+		// update changes the state, it returns whether the item is still
+		// queued.
+		update          func(s *itemState) bool
+		now             time.Time
+		expectedQueued  bool
+		expectedState   string
+		expectedSince   time.Time
+		expectedReadyAt time.Time
+		expectedDirty   bool
+	}{
+		{
+			name: "added",
+			update: func(s *itemState) bool {
+				s.markAdded(at(0), at(0))
+				return true
+			},
+			now:            at(1),
+			expectedQueued: true,
+			expectedState:  StateWaiting,
+			expectedSince:  at(0),
+		},
+		{
+			name: "added after a delay",
+			update: func(s *itemState) bool {
+				s.markAdded(at(0), at(10))
+				return true
+			},
+			now:             at(1),
+			expectedQueued:  true,
+			expectedState:   StateDelayed,
+			expectedSince:   at(0),
+			expectedReadyAt: at(10),
+		},
+		{
+			name: "the earliest addition wins",
+			update: func(s *itemState) bool {
+				s.markAdded(at(0), at(10))
+				s.markAdded(at(1), at(5))
+				s.markAdded(at(2), at(20))
+				return true
+			},
+			now:             at(3),
+			expectedQueued:  true,
+			expectedState:   StateDelayed,
+			expectedSince:   at(0),
+			expectedReadyAt: at(5),
+		},
+		{
+			name: "processing",
+			update: func(s *itemState) bool {
+				s.markAdded(at(0), at(0))
+				s.startProcessing(at(1))
+				return true
+			},
+			now:            at(2),
+			expectedQueued: true,
+			expectedState:  StateProcessing,
+			expectedSince:  at(1),
+		},
+		{
+			name: "added while processing",
+			update: func(s *itemState) bool {
+				s.markAdded(at(0), at(0))
+				s.startProcessing(at(1))
+				s.markAdded(at(2), at(2))
+				return true
+			},
+			now:            at(3),
+			expectedQueued: true,
+			expectedState:  StateProcessing,
+			expectedSince:  at(1),
+			expectedDirty:  true,
+		},
+		{
+			name: "done",
+			update: func(s *itemState) bool {
+				s.markAdded(at(0), at(0))
+				s.startProcessing(at(1))
+				return s.doneProcessing()
+			},
+		},
+		{
+			name: "done after being added while processing",
+			update: func(s *itemState) bool {
+				s.markAdded(at(0), at(0))
+				s.startProcessing(at(1))
+				s.markAdded(at(2), at(12))
+				s.markAdded(at(3), at(8))
+				return s.doneProcessing()
+			},
+			now:             at(4),
+			expectedQueued:  true,
+			expectedState:   StateDelayed,
+			expectedSince:   at(2),
+			expectedReadyAt: at(8),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &itemState{}
+			queued := test.update(s)
+			if queued != test.expectedQueued {
+				t.Fatalf("expected the item to be queued: %t, got %t", test.expectedQueued, queued)
+			}
+			if !queued {
+				return
+			}
+			item := s.item(test.now)
+			if item.State != test.expectedState {
+				t.Errorf("expected the state %s, got %s", test.expectedState, item.State)
+			}
+			if !item.Since.Equal(test.expectedSince) {
+				t.Errorf("expected the item since %s, got %s", test.expectedSince, item.Since)
+			}
+			var readyAt time.Time
+			if item.ReadyAt != nil {
+				readyAt = *item.ReadyAt
+			}
+			if !readyAt.Equal(test.expectedReadyAt) {
+				t.Errorf("expected the item to be ready at %s, got %s", test.expectedReadyAt, readyAt)
+			}
+			if item.AddedWhileProcessing != test.expectedDirty {
+				t.Errorf("expected the item to be added while processing: %t, got %t", test.expectedDirty, item.AddedWhileProcessing)
+			}
+		})
+	}
+}
+
+// getPage requests a page of the items of controller from Handler.
+func getPage(t *testing.T, controller string, query url.Values) (int, Page) {
+	t.Helper()
+	query.Set("controller", controller)
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, DebugPath+"?"+query.Encode(), nil))
+	var page Page
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return rec.Code, page
+}
+
+func TestHandlerPagination(t *testing.T) {
+	q := Register("pagination", "vaQueue", workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[string]()))
+	t.Cleanup(func() {
+		q.ShutDown()
+		mu.Lock()
+		registered = slices.DeleteFunc(registered, func(r Queue) bool { return r.Controller() == "pagination" })
+		mu.Unlock()
+	})
+	var expected []string
+	for i := range 5 {
+		key := fmt.Sprintf("va-%d", i)
+		q.Add(key)
+		expected = append(expected, key)
+		// The items are listed by how long they have waited.
+		time.Sleep(time.Millisecond)
+	}
+
+	var keys []string
+	query := url.Values{"limit": []string{"2"}}
+	for pages := 1; ; pages++ {
+		code, page := getPage(t, "pagination", query)
+		if code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+		}
+		if len(page.Queues) != 1 || page.Queues[0].Depth != 5 {
+			t.Errorf("expected a queue with 5 items, got %+v", page.Queues)
+		}
+		for _, item := range page.Items {
+			keys = append(keys, item.Key)
+		}
+		if page.Continue == "" {
+			if pages != 3 {
+				t.Errorf("expected 3 pages, got %d", pages)
+			}
+			break
+		}
+		query.Set("continue", page.Continue)
+	}
+	if !slices.Equal(keys, expected) {
+		t.Errorf("expected the items %v, got %v", expected, keys)
+	}
+
+	for _, query := range []url.Values{
+		{"limit": []string{"0"}},
+		{"limit": []string{"a"}},
+		{"continue": []string{"-1"}},
+	} {
+		if code, _ := getPage(t, "pagination", query); code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", query.Encode(), http.StatusBadRequest, code)
+		}
+	}
+	// A continue past the last item returns no items.
+	if _, page := getPage(t, "pagination", url.Values{"continue": []string{"10"}}); len(page.Items) != 0 || page.Continue != "" {
+		t.Errorf("expected no items past the last one, got %+v", page)
+	}
+}
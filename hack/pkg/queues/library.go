@@ -0,0 +1,153 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queues
+
+import (
+	"sort"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	basemetrics "k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	// The workqueue metrics are where the depth of the library queues is
+	// read from.
+	_ "k8s.io/component-base/metrics/prometheus/workqueue"
+)
+
+// RateLimiterQueue is the queue of the items reported from the rate limiter
+// of a controller whose queues are created inside a library, the queues
+// share the rate limiter so the queue of an item isn't known.
+const RateLimiterQueue = "rateLimiter"
+
+// libraryQueue is a queue created inside a library, e.g. the claim queue of
+// sig-storage-lib-external-provisioner. Only the workqueue metrics of the
+// queue and the items its rate limiter retries are known.
+type libraryQueue struct {
+	controller string
+	name       string
+	// metricsName is the name the library gave the workqueue, the name
+	// label of its metrics.
+	metricsName string
+}
+
+var libraryQueues []libraryQueue
+
+// metricsGatherer returns the registry the workqueue metrics are registered
+// to.
+var metricsGatherer = func() basemetrics.Gatherer {
+	return legacyregistry.DefaultGatherer
+}
+
+// RegisterLibraryQueue records a queue of controller created inside a
+// library, e.g. the claim queue of the provisioner that
+// sig-storage-lib-external-provisioner names claims:
+//
+//	queues.RegisterLibraryQueue("provisioner", "claimQueue", "claims")
+//
+// /debug/queues lists its depth and requeues from the workqueue metrics, and
+// the items retried by the rate limiter of controller, see
+// NewRetryRateLimiter.
+func RegisterLibraryQueue(controller, name, metricsName string) {
+	mu.Lock()
+	defer mu.Unlock()
+	libraryQueues = append(libraryQueues, libraryQueue{controller: controller, name: name, metricsName: metricsName})
+}
+
+// listLibraryQueues returns the library queues sorted by controller and
+// name.
+func listLibraryQueues() []libraryQueue {
+	mu.RLock()
+	list := append([]libraryQueue(nil), libraryQueues...)
+	mu.RUnlock()
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].controller != list[j].controller {
+			return list[i].controller < list[j].controller
+		}
+		return list[i].name < list[j].name
+	})
+	return list
+}
+
+// workqueueMetrics returns the value of a workqueue metric by queue name.
+func workqueueMetrics(families []*dto.MetricFamily, name string) map[string]float64 {
+	values := map[string]float64{}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.Metric {
+			for _, label := range metric.Label {
+				if label.GetName() != "name" {
+					continue
+				}
+				switch {
+				case metric.Gauge != nil:
+					values[label.GetValue()] = metric.Gauge.GetValue()
+				case metric.Counter != nil:
+					values[label.GetValue()] = metric.Counter.GetValue()
+				}
+			}
+		}
+	}
+	return values
+}
+
+// librarySummaries returns the summaries of the library queues that match
+// filter.
+func librarySummaries(filter func(controller, queue string) bool) []QueueSummary {
+	var summaries []QueueSummary
+	var families []*dto.MetricFamily
+	gathered := false
+	for _, q := range listLibraryQueues() {
+		if !filter(q.controller, q.name) {
+			continue
+		}
+		if !gathered {
+			// The queues are listed even if some metrics can't be gathered.
+			families, _ = metricsGatherer().Gather()
+			gathered = true
+		}
+		summaries = append(summaries, QueueSummary{
+			Controller: q.controller,
+			Queue:      q.name,
+			Kind:       KindForQueue(q.name),
+			Depth:      int(workqueueMetrics(families, "workqueue_depth")[q.metricsName]),
+			Requeues:   int(workqueueMetrics(families, "workqueue_retries_total")[q.metricsName]),
+		})
+	}
+	return summaries
+}
+
+// libraryItems returns the items the rate limiters of the controllers with
+// library queues retry.
+func libraryItems(now time.Time, filter func(controller, queue string) bool) []Item {
+	controllers := map[string]bool{}
+	for _, q := range listLibraryQueues() {
+		controllers[q.controller] = true
+	}
+	var items []Item
+	for _, limiter := range listRateLimiters() {
+		controller := limiter.controllerName()
+		if controllers[controller] && filter(controller, RateLimiterQueue) {
+			items = append(items, limiter.retrying(now)...)
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Since.Before(items[j].Since)
+	})
+	return items
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queues
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	basemetrics "k8s.io/component-base/metrics"
+)
+
+// workqueueRegistry returns a registry with the workqueue metrics of the
+// claims and volumes queues.
+func workqueueRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	depth := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "workqueue_depth", Help: "Depth."}, []string{"name"})
+	retries := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "workqueue_retries_total", Help: "Retries."}, []string{"name"})
+	registry.MustRegister(depth, retries)
+	depth.WithLabelValues("claims").Set(3)
+	depth.WithLabelValues("volumes").Set(1)
+	retries.WithLabelValues("claims").Add(5)
+	return registry
+}
+
+func TestLibraryQueues(t *testing.T) {
+	registry := workqueueRegistry()
+	gatherer := metricsGatherer
+	metricsGatherer = func() basemetrics.Gatherer { return registry }
+	t.Cleanup(func() {
+		mu.Lock()
+		libraryQueues = nil
+		mu.Unlock()
+		rateLimitersMu.Lock()
+		rateLimiters = nil
+		rateLimitersMu.Unlock()
+		metricsGatherer = gatherer
+	})
+	SetBackoff("provisioner", time.Second, time.Minute)
+	t.Cleanup(func() {
+		backoffMu.Lock()
+		delete(backoffs, "provisioner")
+		backoffMu.Unlock()
+	})
+
+	RegisterLibraryQueue("provisioner", "claimQueue", "claims")
+	RegisterLibraryQueue("provisioner", "volumeQueue", "volumes")
+	limiter := NewRetryRateLimiter[any]("provisioner")
+	limiter.When("uid-1")
+	limiter.When("uid-1")
+	limiter.When("pv-1")
+	limiter.When("uid-2")
+	limiter.Forget("uid-2")
+	// The rate limiters of the controllers without library queues are
+	// listed through their registered queues.
+	NewRetryRateLimiter[string]("attacher").When("va-1")
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, DebugPath+"?controller=provisioner", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+	}
+	var page Page
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedQueues := []QueueSummary{
+		{Controller: "provisioner", Queue: "claimQueue", Kind: "PersistentVolumeClaim", Depth: 3, Requeues: 5},
+		{Controller: "provisioner", Queue: "volumeQueue", Depth: 1},
+	}
+	if len(page.Queues) != len(expectedQueues) {
+		t.Fatalf("expected the queues %+v, got %+v", expectedQueues, page.Queues)
+	}
+	for i, expected := range expectedQueues {
+		if page.Queues[i] != expected {
+			t.Errorf("expected the queue %+v, got %+v", expected, page.Queues[i])
+		}
+	}
+
+	expectedItems := map[string]struct {
+		requeues int
+		backoff  string
+	}{
+		"uid-1": {requeues: 2, backoff: "2s"},
+		"pv-1":  {requeues: 1, backoff: "1s"},
+	}
+	if len(page.Items) != len(expectedItems) {
+		t.Fatalf("expected %d items, got %+v", len(expectedItems), page.Items)
+	}
+	for _, item := range page.Items {
+		expected, ok := expectedItems[item.Key]
+		if !ok {
+			t.Errorf("unexpected item %+v", item)
+			continue
+		}
+		if item.Controller != "provisioner" || item.Queue != RateLimiterQueue || item.State != StateRetrying {
+			t.Errorf("expected a retrying item of the provisioner rate limiter, got %+v", item)
+		}
+		if item.Requeues != expected.requeues || item.Backoff != expected.backoff {
+			t.Errorf("expected %d requeues and the backoff %s for %s, got %d and %s", expected.requeues, expected.backoff, item.Key, item.Requeues, item.Backoff)
+		}
+		if item.ReadyAt == nil {
+			t.Errorf("expected %s to be ready after its backoff", item.Key)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package queues keeps track of the workqueues of the sidecar controllers so
+// that the admin API can add items to them and /debug/queues can list them.
+// do_sync.sh wraps the creation of every rate limiting queue of the sidecars
+// with Register.
+//
+// The queues of sig-storage-lib-external-provisioner, e.g. the claim queue of
+// the provisioner, are created inside the library and can't be registered,
+// see MarkUnregistered.
+package queues
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+)
+
+// KindForQueue returns the kind of object the items of a sidecar queue
+// identify, queue is the name of the queue field in the controller, e.g.
+// vaQueue. The keys are <name> for cluster scoped objects and
+// <namespace>/<name> otherwise.
+func KindForQueue(queue string) string {
+	switch strings.ToLower(queue) {
+	case "vaqueue":
+		return "VolumeAttachment"
+	case "pvqueue":
+		return "PersistentVolume"
+	case "claimqueue", "pvcqueue":
+		return "PersistentVolumeClaim"
+	}
+	return ""
+}
+
+// Queue is the part of a registered workqueue the admin API uses.
+type Queue interface {
+	// Controller is the sidecar the queue belongs to.
+	Controller() string
+	// Name is the name of the queue field in the controller, e.g. vaQueue.
+	Name() string
+	// Kind is the kind of object the items identify, see KindForQueue.
+	Kind() string
+	// Enqueue adds the object key to the queue right away.
+	Enqueue(key string) error
+	// EnqueueAfter adds the object key to the queue once delay passed.
+	EnqueueAfter(key string, delay time.Duration) error
+	// Len is the number of items ready to be processed.
+	Len() int
+
+	snapshot(now time.Time) []Item
+}
+
+type registeredQueue[T comparable] struct {
+	workqueue.TypedRateLimitingInterface[T]
+	controller string
+	name       string
+
+	itemsMu sync.Mutex
+	items   map[T]*itemState
+}
+
+func (q *registeredQueue[T]) Controller() string { return q.controller }
+func (q *registeredQueue[T]) Name() string       { return q.name }
+func (q *registeredQueue[T]) Kind() string       { return KindForQueue(q.name) }
+
+func (q *registeredQueue[T]) Enqueue(key string) error {
+	item, ok := any(key).(T)
+	if !ok {
+		return fmt.Errorf("queue %s of %s doesn't hold string keys", q.name, q.controller)
+	}
+	q.Add(item)
+	return nil
+}
+
+func (q *registeredQueue[T]) EnqueueAfter(key string, delay time.Duration) error {
+	item, ok := any(key).(T)
+	if !ok {
+		return fmt.Errorf("queue %s of %s doesn't hold string keys", q.name, q.controller)
+	}
+	q.AddAfter(item, delay)
+	return nil
+}
+
+// Add, AddAfter, AddRateLimited, Get and Done track the state of the items
+// for Snapshot, the workqueue itself doesn't expose its items.
+
+func (q *registeredQueue[T]) Add(item T) {
+	q.track(item, func(s *itemState, now time.Time) {
+		s.markAdded(now, now)
+	})
+	q.TypedRateLimitingInterface.Add(item)
+}
+
+func (q *registeredQueue[T]) AddAfter(item T, duration time.Duration) {
+	q.track(item, func(s *itemState, now time.Time) {
+		s.markAdded(now, now.Add(duration))
+	})
+	q.TypedRateLimitingInterface.AddAfter(item, duration)
+}
+
+func (q *registeredQueue[T]) AddRateLimited(item T) {
+	q.TypedRateLimitingInterface.AddRateLimited(item)
+	requeues := q.NumRequeues(item)
+	q.track(item, func(s *itemState, now time.Time) {
+		s.markAdded(now, now.Add(backoffFor(q.controller, requeues)))
+	})
+}
+
+func (q *registeredQueue[T]) Get() (T, bool) {
+	item, shutdown := q.TypedRateLimitingInterface.Get()
+	if !shutdown {
+		q.track(item, func(s *itemState, now time.Time) {
+			s.startProcessing(now)
+		})
+	}
+	return item, shutdown
+}
+
+func (q *registeredQueue[T]) Done(item T) {
+	q.itemsMu.Lock()
+	if s, ok := q.items[item]; ok && !s.doneProcessing() {
+		delete(q.items, item)
+	}
+	q.itemsMu.Unlock()
+	q.TypedRateLimitingInterface.Done(item)
+}
+
+func (q *registeredQueue[T]) track(item T, update func(s *itemState, now time.Time)) {
+	q.itemsMu.Lock()
+	defer q.itemsMu.Unlock()
+	s, ok := q.items[item]
+	if !ok {
+		s = &itemState{}
+		q.items[item] = s
+	}
+	update(s, time.Now())
+}
+
+// snapshot returns the tracked items sorted by how long they have waited.
+func (q *registeredQueue[T]) snapshot(now time.Time) []Item {
+	q.itemsMu.Lock()
+	keys := make([]T, 0, len(q.items))
+	items := make([]Item, 0, len(q.items))
+	for key, s := range q.items {
+		keys = append(keys, key)
+		items = append(items, s.item(now))
+	}
+	q.itemsMu.Unlock()
+
+	for i, key := range keys {
+		items[i].Controller = q.controller
+		items[i].Queue = q.name
+		items[i].Key = fmt.Sprint(key)
+		items[i].Requeues = q.NumRequeues(key)
+		if items[i].Requeues > 0 {
+			items[i].Backoff = backoffFor(q.controller, items[i].Requeues).String()
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Since.Before(items[j].Since)
+	})
+	return items
+}
+
+var (
+	mu         sync.RWMutex
+	registered []Queue
+	// unregistered maps a controller whose queues can't be registered to the
+	// reason.
+	unregistered = map[string]string{}
+)
+
+// Register records the queue name of controller and returns it, e.g.
+//
+//	vaQueue: queues.Register("attacher", "vaQueue", workqueue.NewTypedRateLimitingQueueWithConfig(...)),
+func Register[T comparable](controller, name string, queue workqueue.TypedRateLimitingInterface[T]) workqueue.TypedRateLimitingInterface[T] {
+	q := &registeredQueue[T]{
+		TypedRateLimitingInterface: queue,
+		controller:                 controller,
+		name:                       name,
+		items:                      map[T]*itemState{},
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	registered = append(registered, q)
+	return q
+}
+
+// MarkUnregistered records that the queues of controller can't be
+// registered, e.g. because a library creates them, reason tells why. The
+// admin API rejects requeues and resyncs of the controller and /debug/queues
+// reports it.
+func MarkUnregistered(controller, reason string) {
+	mu.Lock()
+	defer mu.Unlock()
+	unregistered[controller] = reason
+}
+
+// Unregistered returns why the queues of controller can't be registered.
+func Unregistered(controller string) (string, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	reason, ok := unregistered[controller]
+	return reason, ok
+}
+
+// List returns the registered queues sorted by controller and name.
+func List() []Queue {
+	mu.RLock()
+	list := append([]Queue(nil), registered...)
+	mu.RUnlock()
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Controller() != list[j].Controller() {
+			return list[i].Controller() < list[j].Controller()
+		}
+		return list[i].Name() < list[j].Name()
+	})
+	return list
+}
+
+// Lookup returns the queue name of controller.
+func Lookup(controller, name string) (Queue, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, q := range registered {
+		if q.Controller() == controller && q.Name() == name {
+			return q, true
+		}
+	}
+	return nil, false
+}
+
+// ForController returns the queues of controller, optionally only those
+// holding objects of kind.
+func ForController(controller, kind string) []Queue {
+	var list []Queue
+	for _, q := range List() {
+		if q.Controller() == controller && (kind == "" || q.Kind() == kind) {
+			list = append(list, q)
+		}
+	}
+	return list
+}
+
+// Key returns the queue key of an object.
+func Key(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
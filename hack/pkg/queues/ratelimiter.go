@@ -0,0 +1,140 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queues
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+)
+
+// NewRetryRateLimiter returns the exponential failure rate limiter of
+// controller, like workqueue.NewTypedItemExponentialFailureRateLimiter
+// with --retry-interval-start and --retry-interval-max, except that it reads
+// the intervals set with SetBackoff on every failure so that they can be
+// changed while the controller runs. do_sync.sh replaces the rate limiters
+// of the sidecars built from the retry intervals with it.
+//
+// The items it retries are listed by /debug/queues for the controllers whose
+// queues are created inside a library, see RegisterLibraryQueue.
+func NewRetryRateLimiter[T comparable](controller string) workqueue.TypedRateLimiter[T] {
+	r := &retryRateLimiter[T]{
+		controller: controller,
+		failures:   map[T]*failure{},
+	}
+	rateLimitersMu.Lock()
+	defer rateLimitersMu.Unlock()
+	rateLimiters = append(rateLimiters, r)
+	return r
+}
+
+// rateLimiter is the part of a retry rate limiter /debug/queues uses.
+type rateLimiter interface {
+	controllerName() string
+	// retrying returns the items that failed and weren't forgotten yet.
+	retrying(now time.Time) []Item
+}
+
+var (
+	rateLimitersMu sync.RWMutex
+	rateLimiters   []rateLimiter
+)
+
+func listRateLimiters() []rateLimiter {
+	rateLimitersMu.RLock()
+	defer rateLimitersMu.RUnlock()
+	return append([]rateLimiter(nil), rateLimiters...)
+}
+
+// failure records the rate limited requeues of an item.
+type failure struct {
+	count int
+	// since is when the item failed first.
+	since time.Time
+	// readyAt is when the latest requeue is handed to the workers.
+	readyAt time.Time
+	backoff time.Duration
+}
+
+type retryRateLimiter[T comparable] struct {
+	controller string
+
+	mu       sync.Mutex
+	failures map[T]*failure
+}
+
+func (r *retryRateLimiter[T]) When(item T) time.Duration {
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, ok := r.failures[item]
+	if !ok {
+		f = &failure{since: now}
+		r.failures[item] = f
+	}
+	f.count++
+	f.backoff = backoffFor(r.controller, f.count)
+	f.readyAt = now.Add(f.backoff)
+	return f.backoff
+}
+
+func (r *retryRateLimiter[T]) Forget(item T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.failures, item)
+}
+
+func (r *retryRateLimiter[T]) NumRequeues(item T) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if f, ok := r.failures[item]; ok {
+		return f.count
+	}
+	return 0
+}
+
+func (r *retryRateLimiter[T]) controllerName() string { return r.controller }
+
+func (r *retryRateLimiter[T]) retrying(now time.Time) []Item {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	items := make([]Item, 0, len(r.failures))
+	for key, f := range r.failures {
+		item := Item{
+			Controller: r.controller,
+			Queue:      RateLimiterQueue,
+			Key:        fmt.Sprint(key),
+			State:      StateRetrying,
+			Since:      f.since,
+			Waiting:    now.Sub(f.since).Round(time.Millisecond).String(),
+			Requeues:   f.count,
+			Backoff:    f.backoff.String(),
+		}
+		if f.readyAt.After(now) {
+			readyAt := f.readyAt
+			item.ReadyAt = &readyAt
+		}
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Key < items[j].Key
+	})
+	return items
+}
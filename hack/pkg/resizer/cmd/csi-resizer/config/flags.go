@@ -0,0 +1,42 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/kubernetes-csi/csi-sidecars/pkg/featuregates"
+	"github.com/kubernetes-csi/csi-sidecars/pkg/resizer/pkg/features"
+)
+
+type ResizerConfiguration struct {
+	HandleVolumeInUseError bool
+	ExtraModifyMetadata    bool
+
+	// prefix of the flags, see Validate.
+	prefix string
+}
+
+func registerResizerFlags(flags *flag.FlagSet, configuration *ResizerConfiguration, prefix string) {
+	configuration.prefix = prefix
+	flags.BoolVar(&configuration.HandleVolumeInUseError, prefix+"handle-volume-inuse-error", true, "Flag to turn on/off capability to handle volume in use error in resizer controller. Defaults to true if not set.")
+	flags.BoolVar(&configuration.ExtraModifyMetadata, prefix+"extra-modify-metadata", false, "If set, add pv/pvc metadata to plugin modify requests as parameters.")
+}
+
+func RegisterResizerFlags(flags *flag.FlagSet, configuration *ResizerConfiguration) {
+	registerResizerFlags(flags, configuration, "")
+}
+
+func RegisterResizerFlagsWithPrefix(flags *flag.FlagSet, configuration *ResizerConfiguration) {
+	registerResizerFlags(flags, configuration, "resizer-")
+}
+
+// Validate returns every invalid flag value of the configuration, the
+// feature gates of the resizer must be set.
+func (c *ResizerConfiguration) Validate() error {
+	// The resizer only modifies volumes with the VolumeAttributesClass
+	// feature gate.
+	if c.ExtraModifyMetadata && !featuregates.For("resizer").Enabled(features.VolumeAttributesClass) {
+		return fmt.Errorf("--%sextra-modify-metadata requires the VolumeAttributesClass feature gate", c.prefix)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestRegisterResizerFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func(*flag.FlagSet, *ResizerConfiguration)
+		args     []string
+		expected ResizerConfiguration
+	}{
+		{
+			name:     "unprefixed defaults",
+			register: RegisterResizerFlags,
+			expected: ResizerConfiguration{HandleVolumeInUseError: true},
+		},
+		{
+			name:     "unprefixed",
+			register: RegisterResizerFlags,
+			args:     []string{"--handle-volume-inuse-error=false", "--extra-modify-metadata"},
+			expected: ResizerConfiguration{HandleVolumeInUseError: false, ExtraModifyMetadata: true},
+		},
+		{
+			name:     "prefixed defaults",
+			register: RegisterResizerFlagsWithPrefix,
+			expected: ResizerConfiguration{HandleVolumeInUseError: true, prefix: "resizer-"},
+		},
+		{
+			name:     "prefixed",
+			register: RegisterResizerFlagsWithPrefix,
+			args:     []string{"--resizer-handle-volume-inuse-error=false", "--resizer-extra-modify-metadata"},
+			expected: ResizerConfiguration{HandleVolumeInUseError: false, ExtraModifyMetadata: true, prefix: "resizer-"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			var c ResizerConfiguration
+			test.register(flags, &c)
+			if err := flags.Parse(test.args); err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if c != test.expected {
+				t.Errorf("expected %+v, got %+v", test.expected, c)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Span attributes identifying the Kubernetes objects a sidecar operates on.
+const (
+	ControllerKey            = attribute.Key("csi.sidecar.controller")
+	NamespaceKey             = attribute.Key("k8s.namespace.name")
+	PersistentVolumeKey      = attribute.Key("k8s.persistentvolume.name")
+	PersistentVolumeClaimKey = attribute.Key("k8s.persistentvolumeclaim.name")
+	VolumeAttachmentKey      = attribute.Key("k8s.volumeattachment.name")
+
+	resourceKey    = attribute.Key("k8s.resource")
+	subresourceKey = attribute.Key("k8s.subresource")
+)
+
+var kindForResource = map[string]string{
+	"persistentvolumes":      "PersistentVolume",
+	"persistentvolumeclaims": "PersistentVolumeClaim",
+	"volumeattachments":      "VolumeAttachment",
+}
+
+// WrapTransport returns a rest.Config WrapperFunc that records a span for
+// every Kubernetes API call made by controller, e.g.
+//
+//	config.Wrap(tracing.WrapTransport("attacher"))
+func WrapTransport(controller string) func(http.RoundTripper) http.RoundTripper {
+	return func(rt http.RoundTripper) http.RoundTripper {
+		return otelhttp.NewTransport(
+			&objectAttributesRoundTripper{controller: controller, delegate: rt},
+			otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+				return "kubernetes " + r.Method + " " + resourceFromPath(r.URL.Path)
+			}),
+		)
+	}
+}
+
+// objectAttributesRoundTripper adds the controller and the object identifiers
+// found in the request path to the span started by otelhttp.
+type objectAttributesRoundTripper struct {
+	controller string
+	delegate   http.RoundTripper
+}
+
+func (rt *objectAttributesRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	span := trace.SpanFromContext(r.Context())
+	if span.IsRecording() {
+		span.SetAttributes(ControllerKey.String(rt.controller))
+		span.SetAttributes(objectAttributesFromPath(r.URL.Path)...)
+	}
+	return rt.delegate.RoundTrip(r)
+}
+
+// resourceFromPath returns the resource of an API path, e.g. volumeattachments
+// for /apis/storage.k8s.io/v1/volumeattachments/foo.
+func resourceFromPath(path string) string {
+	resource, _, _, _ := parsePath(path)
+	if resource == "" {
+		return path
+	}
+	return resource
+}
+
+// ObjectAttributes returns the attributes identifying a PersistentVolume,
+// PersistentVolumeClaim or VolumeAttachment.
+func ObjectAttributes(kind, namespace, name string) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if namespace != "" {
+		attrs = append(attrs, NamespaceKey.String(namespace))
+	}
+	switch kind {
+	case "PersistentVolume":
+		attrs = append(attrs, PersistentVolumeKey.String(name))
+	case "PersistentVolumeClaim":
+		attrs = append(attrs, PersistentVolumeClaimKey.String(name))
+	case "VolumeAttachment":
+		attrs = append(attrs, VolumeAttachmentKey.String(name))
+	}
+	return attrs
+}
+
+// objectAttributesFromPath returns the object identifiers of an API path.
+func objectAttributesFromPath(path string) []attribute.KeyValue {
+	resource, namespace, name, subresource := parsePath(path)
+	if resource == "" {
+		return nil
+	}
+	attrs := []attribute.KeyValue{resourceKey.String(resource)}
+	if subresource != "" {
+		attrs = append(attrs, subresourceKey.String(subresource))
+	}
+	if name == "" {
+		if namespace != "" {
+			attrs = append(attrs, NamespaceKey.String(namespace))
+		}
+		return attrs
+	}
+	return append(attrs, ObjectAttributes(kindForResource[resource], namespace, name)...)
+}
+
+// parsePath splits /api/v1/[namespaces/<ns>/]<resource>[/<name>[/<subresource>]]
+// and its /apis/<group>/<version>/... counterpart.
+func parsePath(path string) (resource, namespace, name, subresource string) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case len(parts) >= 3 && parts[0] == "api":
+		parts = parts[2:]
+	case len(parts) >= 4 && parts[0] == "apis":
+		parts = parts[3:]
+	default:
+		return "", "", "", ""
+	}
+	if len(parts) >= 3 && parts[0] == "namespaces" {
+		namespace = parts[1]
+		parts = parts[2:]
+	}
+	resource = parts[0]
+	if len(parts) > 1 {
+		name = parts[1]
+	}
+	if len(parts) > 2 {
+		subresource = parts[2]
+	}
+	return resource, namespace, name, subresource
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing sets up OpenTelemetry tracing for the CSI sidecars.
+//
+// Spans are recorded for workqueue items, Kubernetes API calls and CSI RPCs.
+// The trace context of CSI RPCs is propagated to the driver as gRPC metadata
+// (W3C traceparent), so a driver can continue the trace of the sidecar
+// operation that called it.
+package tracing
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// TracerName is the instrumentation scope of the spans recorded by the sidecars.
+	TracerName = "github.com/kubernetes-csi/csi-sidecars"
+
+	serviceName = "csi-sidecars"
+)
+
+// Exporters supported by --tracing-exporter.
+const (
+	ExporterNone   = ""
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+)
+
+// Options configures tracing.
+type Options struct {
+	Exporter      string
+	OTLPEndpoint  string
+	File          string
+	SamplingRatio float64
+}
+
+// AddFlags registers the tracing flags.
+func (o *Options) AddFlags(flags *flag.FlagSet) {
+	flags.StringVar(&o.Exporter, "tracing-exporter", ExporterNone, "Export OpenTelemetry traces of workqueue items, Kubernetes API calls and CSI RPCs. The possible values are: [otlp,stdout,file]. The default is empty string, which means tracing is disabled.")
+	flags.StringVar(&o.OTLPEndpoint, "tracing-otlp-endpoint", "", "The OTLP gRPC collector URL (example: `http://otel-collector:4317`) used with --tracing-exporter=otlp. If empty, the OTEL_EXPORTER_OTLP_* environment variables are used.")
+	flags.StringVar(&o.File, "tracing-file", "", "The file spans are appended to as JSON with --tracing-exporter=file.")
+	flags.Float64Var(&o.SamplingRatio, "tracing-sampling-ratio", 1, "The fraction of new traces that are sampled, between 0 and 1. Traces started by a sampled parent are always sampled.")
+}
+
+// Validate checks that the options are consistent.
+func (o *Options) Validate() error {
+	switch o.Exporter {
+	case ExporterNone, ExporterOTLP, ExporterStdout:
+	case ExporterFile:
+		if o.File == "" {
+			return fmt.Errorf("--tracing-exporter=%s requires --tracing-file", ExporterFile)
+		}
+	default:
+		return fmt.Errorf("unknown --tracing-exporter %q", o.Exporter)
+	}
+	if o.SamplingRatio < 0 || o.SamplingRatio > 1 {
+		return fmt.Errorf("--tracing-sampling-ratio must be between 0 and 1, got %v", o.SamplingRatio)
+	}
+	return nil
+}
+
+// Setup installs the global tracer provider and the W3C trace context
+// propagator followed by the extra propagators, e.g. the one that adds the
+// operation of a request to the metadata of CSI RPCs. The propagators are
+// installed even when tracing is disabled.
+// The returned function flushes pending spans and must be called before the
+// process exits.
+func Setup(ctx context.Context, o *Options, extraPropagators ...propagation.TextMapPropagator) (func(context.Context) error, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	propagators := append([]propagation.TextMapPropagator{propagation.TraceContext{}, propagation.Baggage{}}, extraPropagators...)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagators...))
+	if o.Exporter == ExporterNone {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, closer, err := newExporter(ctx, o)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the %s trace exporter: %w", o.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", serviceName)))
+	if err != nil {
+		return nil, err
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(o.SamplingRatio))),
+	)
+	otel.SetTracerProvider(tp)
+
+	return func(ctx context.Context) error {
+		err := tp.Shutdown(ctx)
+		if closer != nil {
+			closer.Close()
+		}
+		return err
+	}, nil
+}
+
+func newExporter(ctx context.Context, o *Options) (sdktrace.SpanExporter, io.Closer, error) {
+	switch o.Exporter {
+	case ExporterOTLP:
+		var opts []otlptracegrpc.Option
+		if o.OTLPEndpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpointURL(o.OTLPEndpoint))
+		}
+		exporter, err := otlptracegrpc.New(ctx, opts...)
+		return exporter, nil, err
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		return exporter, nil, err
+	case ExporterFile:
+		f, err := os.OpenFile(o.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, err
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		return exporter, f, nil
+	}
+	return nil, nil, fmt.Errorf("unknown exporter %q", o.Exporter)
+}
+
+// StartSpan starts a span with the tracer of the sidecars.
+func StartSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(TracerName).Start(ctx, name, opts...)
+}
@@ -0,0 +1,44 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	queueKey    = attribute.Key("workqueue.name")
+	queueKeyKey = attribute.Key("workqueue.key")
+)
+
+// StartDequeueSpan starts the span of a workqueue item that was just
+// dequeued, the caller ends it when the item is marked as done. The returned
+// context carries the span, the sync function of the item passes it to its
+// Kubernetes API calls and CSI RPCs so that they're recorded in the trace of
+// the item.
+// queue is the name of the queue field in the controller, e.g. vaQueue, the
+// caller adds the identifiers of the object of the item, see ObjectAttributes.
+func StartDequeueSpan(ctx context.Context, queue string, key any) (context.Context, trace.Span) {
+	return StartSpan(ctx, "workqueue "+queue,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(queueKey.String(queue), queueKeyKey.String(fmt.Sprint(key))),
+	)
+}